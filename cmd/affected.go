@@ -7,7 +7,8 @@ import (
 	"slices"
 	"strings"
 
-	"github.com/kperreau/goac/pkg/printer"
+	"github.com/kperreau/goac/pkg/hasher"
+	"github.com/kperreau/goac/pkg/logger"
 	"github.com/kperreau/goac/pkg/project"
 	"github.com/spf13/cobra"
 )
@@ -24,24 +25,67 @@ var affectedCmd = &cobra.Command{
 			return err
 		}
 
+		algorithm, err := hasher.ParseAlgorithm(hashAlgorithm)
+		if err != nil {
+			return err
+		}
+
+		reportsArgs, err := reportsCmd(reports)
+		if err != nil {
+			return err
+		}
+
+		shardArg, err := shardCmd(shard)
+		if err != nil {
+			return err
+		}
+
 		t := project.StringToTarget(target)
 		if project.StringToTarget(target) != project.TargetNone {
-			projectsList, err := project.NewProjectsList(&project.Options{
-				Target:         t,
-				DryRun:         dryrun,
-				MaxConcurrency: concurrency,
-				BinaryCheck:    binaryCheck,
-				Force:          force,
-				DockerIgnore:   dockerignore,
-				Debug:          debugArgs,
-				ProjectsName:   projectsCmd(projects),
-				PrintStdout:    stdout,
+			buildAll := keepGoingCmd(keepGoing, failFast)
+			projectsList, loadErr := project.NewProjectsList(cmd.Context(), &project.Options{
+				Target:           t,
+				DryRun:           dryrun,
+				MaxConcurrency:   concurrency,
+				BinaryCheck:      binaryCheck,
+				Force:            force,
+				DockerIgnore:     dockerignore,
+				Debug:            debugArgs,
+				ProjectsName:     projectsCmd(projects),
+				Tags:             projectsCmd(tags),
+				Paths:            projectsCmd(paths),
+				Exclude:          projectsCmd(exclude),
+				WithDependents:   withDependents,
+				WithDependencies: withDependencies,
+				PrintStdout:      stdout,
+				HashAlgorithm:    algorithm,
+				SemanticHash:     semanticHash,
+				KeepGoing:        buildAll,
+				Shard:            shardArg,
+				PrintShardPlan:   shardPlan,
+				NoDaemon:         noDaemon,
+				Tracer:           newTracer(),
 			})
+			// with keep going, the projects that loaded are built even if others failed to load
+			if projectsList == nil || (loadErr != nil && !buildAll) {
+				return loadErr
+			}
+			if loadErr != nil {
+				project.LogError(logger.Default(), loadErr)
+			}
+			err = projectsList.Affected(cmd.Context())
+			if projectsList.Summary() != nil {
+				for _, r := range reportsArgs {
+					if err := projectsList.Summary().WriteReport(r); err != nil {
+						logger.Errorf("error writing %s report: %s\n", r.Format, err)
+					}
+				}
+			}
 			if err != nil {
-				return err
+				project.LogError(logger.Default(), err)
 			}
-			if err := projectsList.Affected(); err != nil {
-				printer.Errorf("%s\n", err)
+			if err != nil || loadErr != nil {
+				exportTrace(cmd.Context())
 				os.Exit(1)
 			}
 			return nil
@@ -66,6 +110,12 @@ var (
 	binaryCheck  bool
 	dockerignore bool
 	stdout       bool
+	keepGoing    bool
+	failFast     bool
+	summaryJSON  string
+	reports      []string
+	shard        string
+	shardPlan    bool
 )
 
 func debugCmd(arg string) ([]string, error) {
@@ -82,6 +132,42 @@ func debugCmd(arg string) ([]string, error) {
 	return args, nil
 }
 
+func reportsCmd(args []string) ([]*project.Report, error) {
+	var parsed []*project.Report
+	if summaryJSON != "" {
+		parsed = append(parsed, &project.Report{Format: project.ReportJSON, Path: summaryJSON})
+	}
+
+	for _, arg := range args {
+		r, err := project.ParseReport(arg)
+		if err != nil {
+			return nil, err
+		}
+		parsed = append(parsed, r)
+	}
+
+	return parsed, nil
+}
+
+// addKeepGoingFlags adds --keep-going and --fail-fast, --fail-fast=false being an alias of --keep-going.
+func addKeepGoingFlags(cmd *cobra.Command, keepGoing *bool, failFast *bool) {
+	cmd.Flags().BoolVar(keepGoing, "keep-going", false, "Build every affected project even if some fail, same as --fail-fast=false")
+	cmd.Flags().BoolVar(failFast, "fail-fast", true, "Skip the projects not started yet after the first failure")
+	cmd.MarkFlagsMutuallyExclusive("keep-going", "fail-fast")
+}
+
+// keepGoingCmd returns if every affected project is built after a failure, with --keep-going or --fail-fast=false.
+func keepGoingCmd(keepGoing bool, failFast bool) bool {
+	return keepGoing || !failFast
+}
+
+func shardCmd(arg string) (*project.Shard, error) {
+	if arg == "" {
+		return nil, nil
+	}
+	return project.ParseShard(arg)
+}
+
 func init() {
 	rootCmd.AddCommand(affectedCmd)
 
@@ -91,7 +177,13 @@ func init() {
 	affectedCmd.Flags().BoolVar(&binaryCheck, "binarycheck", false, "Affected if binary is missing")
 	affectedCmd.Flags().BoolVar(&dryrun, "dryrun", false, "Dry & run")
 	affectedCmd.Flags().BoolVarP(&force, "force", "f", false, "Force build")
-	affectedCmd.Flags().StringVarP(&projects, "projects", "p", "", "Filter by projects name")
+	addSelectorFlags(affectedCmd)
+	addGraphFlags(affectedCmd)
 	affectedCmd.Flags().StringVar(&debug, "debug", "", "Display some data to debug")
 	affectedCmd.Flags().IntVarP(&concurrency, "concurrency", "c", 4, "Max Concurrency")
+	addKeepGoingFlags(affectedCmd, &keepGoing, &failFast)
+	affectedCmd.Flags().StringVar(&summaryJSON, "summary-json", "", "Write the summary of the run to a JSON file")
+	affectedCmd.Flags().StringArrayVar(&reports, "report", nil, "Write a report of the run as format=path, formats: json,junit,markdown")
+	affectedCmd.Flags().StringVar(&shard, "shard", "", "Run the part N/M of the affected projects, balanced on build durations")
+	affectedCmd.Flags().BoolVar(&shardPlan, "shard-plan", false, "Print the affected projects of every shard")
 }
@@ -3,6 +3,8 @@ package cmd
 import (
 	"testing"
 
+	"github.com/kperreau/goac/pkg/project"
+	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -45,3 +47,49 @@ func TestDebugCmd_InvalidArgsWithValid(t *testing.T) {
 	assert.Equal(t, errorMsg, err.Error())
 	assert.Equal(t, expected, result)
 }
+
+func TestReportsCmd_ValidArgs(t *testing.T) {
+	result, err := reportsCmd([]string{"junit=report.xml", "markdown=report.md"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []*project.Report{
+		{Format: project.ReportJUnit, Path: "report.xml"},
+		{Format: project.ReportMarkdown, Path: "report.md"},
+	}, result)
+}
+
+func TestReportsCmd_InvalidFormat(t *testing.T) {
+	_, err := reportsCmd([]string{"html=report.html"})
+
+	assert.Error(t, err)
+}
+
+func TestKeepGoingCmd_Flags(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected bool
+	}{
+		{args: nil, expected: false},
+		{args: []string{"--fail-fast=false"}, expected: true},
+		{args: []string{"--fail-fast=true"}, expected: false},
+		{args: []string{"--keep-going"}, expected: true},
+	}
+
+	for _, tt := range tests {
+		var keepGoing, failFast bool
+		cmd := &cobra.Command{}
+		addKeepGoingFlags(cmd, &keepGoing, &failFast)
+
+		assert.NoError(t, cmd.ParseFlags(tt.args))
+		assert.Equal(t, tt.expected, keepGoingCmd(keepGoing, failFast), tt.args)
+	}
+}
+
+func TestKeepGoingCmd_MutuallyExclusiveFlags(t *testing.T) {
+	var keepGoing, failFast bool
+	cmd := &cobra.Command{}
+	addKeepGoingFlags(cmd, &keepGoing, &failFast)
+
+	assert.NoError(t, cmd.ParseFlags([]string{"--keep-going", "--fail-fast=false"}))
+	assert.Error(t, cmd.ValidateFlagGroups())
+}
@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+
+	"github.com/kperreau/goac/pkg/hasher"
+	"github.com/kperreau/goac/pkg/logger"
+	"github.com/kperreau/goac/pkg/project"
+	"github.com/kperreau/goac/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// cacheCmd represents the cache command group
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage GOAC cache",
+	Long:  `Use it to inspect, prune, clean and verify the GOAC cache entries.`,
+}
+
+var cacheLsCmd = &cobra.Command{
+	Use:     "ls",
+	Example: "goac cache ls",
+	Short:   "List cache entries",
+	Long:    `List cache entries with their project name, path, target and date.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) > 0 {
+			return errors.New("bad args number")
+		}
+
+		return project.CacheList(project.DefaultCachePath)
+	},
+}
+
+var cacheShowCmd = &cobra.Command{
+	Use:     "show <project>",
+	Example: "goac cache show goac",
+	Short:   "Show a cache entry",
+	Long:    `Show the cache entry of a project, by project name, project path or cache key.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("bad args number")
+		}
+
+		return project.CacheShow(project.DefaultCachePath, args[0])
+	},
+}
+
+var cachePruneCmd = &cobra.Command{
+	Use:     "prune",
+	Example: "goac cache prune --older-than 30d",
+	Short:   "Prune cache entries",
+	Long:    `Remove cache entries whose project no longer exists, and optionally those older than a duration.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) > 0 {
+			return errors.New("bad args number")
+		}
+
+		opts := &project.CachePruneOptions{DryRun: dryrun}
+		if olderThan != "" {
+			d, err := utils.ParseDuration(olderThan)
+			if err != nil {
+				return err
+			}
+			opts.OlderThan = d
+		}
+
+		return project.CachePrune(project.DefaultCachePath, opts)
+	},
+}
+
+var cacheCleanCmd = &cobra.Command{
+	Use:     "clean",
+	Example: "goac cache clean -p goac -t build",
+	Short:   "Clean cache entries",
+	Long:    `Remove cache entries, optionally filtered by projects and target.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) > 0 {
+			return errors.New("bad args number")
+		}
+
+		t := project.StringToTarget(target)
+		if target != "" && t == project.TargetNone {
+			return errors.New("bad target")
+		}
+
+		return project.CacheClean(project.DefaultCachePath, &project.CacheCleanOptions{
+			ProjectsName: projectsCmd(projects),
+			Tags:         projectsCmd(tags),
+			Paths:        projectsCmd(paths),
+			Exclude:      projectsCmd(exclude),
+			Target:       t,
+			DryRun:       dryrun,
+		})
+	},
+}
+
+var cacheVerifyCmd = &cobra.Command{
+	Use:     "verify",
+	Example: "goac cache verify -t build",
+	Short:   "Verify cache entries",
+	Long:    `Verify cache entries of a target against freshly computed hashes.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) > 0 {
+			return errors.New("bad args number")
+		}
+
+		t := project.StringToTarget(target)
+		if t == project.TargetNone {
+			return errors.New("bad argument")
+		}
+
+		algorithm, err := hasher.ParseAlgorithm(hashAlgorithm)
+		if err != nil {
+			return err
+		}
+
+		projectsList, err := project.NewProjectsList(cmd.Context(), &project.Options{
+			Target:           t,
+			DryRun:           true,
+			MaxConcurrency:   concurrency,
+			DockerIgnore:     dockerignore,
+			ProjectsName:     projectsCmd(projects),
+			Tags:             projectsCmd(tags),
+			Paths:            projectsCmd(paths),
+			Exclude:          projectsCmd(exclude),
+			WithDependents:   withDependents,
+			WithDependencies: withDependencies,
+			HashAlgorithm:    algorithm,
+			SemanticHash:     semanticHash,
+			NoDaemon:         noDaemon,
+			Tracer:           newTracer(),
+		})
+		if err != nil {
+			return err
+		}
+		if err := projectsList.VerifyCache(); err != nil {
+			project.LogError(logger.Default(), err)
+			exportTrace(cmd.Context())
+			os.Exit(1)
+		}
+
+		return nil
+	},
+}
+
+var olderThan string
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheLsCmd, cacheShowCmd, cachePruneCmd, cacheCleanCmd, cacheVerifyCmd)
+
+	cachePruneCmd.Flags().StringVar(&olderThan, "older-than", "", "Also prune entries older than this duration (e.g. 72h, 30d)")
+	cachePruneCmd.Flags().BoolVar(&dryrun, "dryrun", false, "Dry & run")
+
+	addSelectorFlags(cacheCleanCmd)
+	cacheCleanCmd.Flags().StringVarP(&target, "target", "t", "", "Target")
+	cacheCleanCmd.Flags().BoolVar(&dryrun, "dryrun", false, "Dry & run")
+
+	cacheVerifyCmd.Flags().StringVarP(&target, "target", "t", "", "Target")
+	addSelectorFlags(cacheVerifyCmd)
+	addGraphFlags(cacheVerifyCmd)
+	cacheVerifyCmd.Flags().BoolVar(&dockerignore, "dockerignore", true, "Read docker ignore")
+	cacheVerifyCmd.Flags().IntVarP(&concurrency, "concurrency", "c", 4, "Max Concurrency")
+}
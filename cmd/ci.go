@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/kperreau/goac/pkg/ci"
+	"github.com/kperreau/goac/pkg/hasher"
+	"github.com/kperreau/goac/pkg/project"
+	"github.com/spf13/cobra"
+)
+
+// ciCmd represents the ci command
+var ciCmd = &cobra.Command{
+	Use:   "ci",
+	Short: "Generate CI pipelines",
+	Long:  `Generate CI pipelines building the affected projects in parallel jobs.`,
+}
+
+var ciMatrixCmd = &cobra.Command{
+	Use: "matrix",
+	Example: `goac ci matrix -t build
+goac ci matrix -t build,build-image --format gitlab -o child-pipeline.yml`,
+	Short: "Generate a matrix of the affected projects",
+	Long: `Generate a GitHub Actions strategy.matrix JSON, or a GitLab child pipeline YAML,
+with one job per affected project and target.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) > 0 {
+			return errors.New("bad args number")
+		}
+
+		format, err := ci.ParseFormat(ciFormat)
+		if err != nil {
+			return err
+		}
+
+		jobs, err := ciJobs(cmd.Context(), projectsCmd(target))
+		if err != nil {
+			return err
+		}
+
+		var data []byte
+		switch format {
+		case ci.FormatGitHub:
+			data, err = ci.GitHubMatrix(jobs)
+			data = append(data, '\n')
+		case ci.FormatGitLab:
+			data, err = ci.GitLabPipeline(jobs, ci.GitLabOptions{Stage: ciStage, Image: ciImage})
+		}
+		if err != nil {
+			return err
+		}
+
+		if ciOutput == "" {
+			_, err = os.Stdout.Write(data)
+			return err
+		}
+		return os.WriteFile(ciOutput, data, 0o644)
+	},
+}
+
+var (
+	ciFormat string
+	ciOutput string
+	ciStage  string
+	ciImage  string
+)
+
+// ciJobs returns a job per affected project of each target, in the order of the targets.
+func ciJobs(ctx context.Context, targets []string) ([]ci.Job, error) {
+	if len(targets) == 0 {
+		return nil, errors.New("missing target")
+	}
+
+	algorithm, err := hasher.ParseAlgorithm(hashAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	flags := ciFlags(algorithm)
+	var jobs []ci.Job
+	for _, name := range targets {
+		t := project.StringToTarget(name)
+		if t == project.TargetNone {
+			return nil, fmt.Errorf("bad target: %s", name)
+		}
+
+		projectsList, err := project.NewProjectsList(ctx, &project.Options{
+			Target:           t,
+			DryRun:           true,
+			MaxConcurrency:   concurrency,
+			BinaryCheck:      binaryCheck,
+			Force:            force,
+			DockerIgnore:     dockerignore,
+			ProjectsName:     projectsCmd(projects),
+			Tags:             projectsCmd(tags),
+			Paths:            projectsCmd(paths),
+			Exclude:          projectsCmd(exclude),
+			WithDependents:   withDependents,
+			WithDependencies: withDependencies,
+			HashAlgorithm:    algorithm,
+			SemanticHash:     semanticHash,
+			NoDaemon:         noDaemon,
+			Tracer:           newTracer(),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, r := range projectsList.AffectedProjects() {
+			jobs = append(jobs, ci.Job{Project: r.Name, Path: r.Path, Target: t.String(), Reason: r.Reason, Flags: flags})
+		}
+	}
+
+	return jobs, nil
+}
+
+// ciFlags returns the flags changing the affected projects which are not set to their default,
+// the jobs build with them.
+func ciFlags(algorithm hasher.Algorithm) string {
+	var flags []string
+	if algorithm != hasher.SHA1 {
+		flags = append(flags, "--hash-algorithm "+string(algorithm))
+	}
+	if semanticHash {
+		flags = append(flags, "--semantic-hash")
+	}
+	if !dockerignore {
+		flags = append(flags, "--dockerignore=false")
+	}
+	if binaryCheck {
+		flags = append(flags, "--binarycheck")
+	}
+	if force {
+		flags = append(flags, "--force")
+	}
+	return strings.Join(flags, " ")
+}
+
+func init() {
+	rootCmd.AddCommand(ciCmd)
+	ciCmd.AddCommand(ciMatrixCmd)
+
+	ciMatrixCmd.Flags().StringVarP(&target, "target", "t", "", "Targets, comma separated")
+	ciMatrixCmd.Flags().StringVar(&ciFormat, "format", string(ci.FormatGitHub), "Output format: github,gitlab")
+	ciMatrixCmd.Flags().StringVarP(&ciOutput, "output", "o", "", "Write to a file instead of stdout")
+	ciMatrixCmd.Flags().StringVar(&ciStage, "stage", "build", "Stage of the GitLab jobs")
+	ciMatrixCmd.Flags().StringVar(&ciImage, "image", "", "Docker image of the GitLab jobs")
+	addSelectorFlags(ciMatrixCmd)
+	addGraphFlags(ciMatrixCmd)
+	ciMatrixCmd.Flags().BoolVar(&dockerignore, "dockerignore", true, "Read docker ignore")
+	ciMatrixCmd.Flags().BoolVar(&binaryCheck, "binarycheck", false, "Affected if binary is missing")
+	ciMatrixCmd.Flags().BoolVarP(&force, "force", "f", false, "Force build")
+	ciMatrixCmd.Flags().IntVarP(&concurrency, "concurrency", "c", 4, "Max Concurrency")
+}
@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/kperreau/goac/pkg/hasher"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCiFlags_NonDefaultValues(t *testing.T) {
+	oldSemanticHash, oldDockerignore, oldBinaryCheck, oldForce := semanticHash, dockerignore, binaryCheck, force
+	defer func() {
+		semanticHash, dockerignore, binaryCheck, force = oldSemanticHash, oldDockerignore, oldBinaryCheck, oldForce
+	}()
+	semanticHash, dockerignore, binaryCheck, force = true, false, true, true
+
+	flags := ciFlags(hasher.SHA256)
+
+	assert.Equal(t, "--hash-algorithm sha256 --semantic-hash --dockerignore=false --binarycheck --force", flags)
+}
+
+func TestCiFlags_Defaults(t *testing.T) {
+	oldSemanticHash, oldDockerignore, oldBinaryCheck, oldForce := semanticHash, dockerignore, binaryCheck, force
+	defer func() {
+		semanticHash, dockerignore, binaryCheck, force = oldSemanticHash, oldDockerignore, oldBinaryCheck, oldForce
+	}()
+	semanticHash, dockerignore, binaryCheck, force = false, true, false, false
+
+	assert.Empty(t, ciFlags(hasher.SHA1))
+}
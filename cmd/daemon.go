@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"errors"
+	"time"
+
+	"github.com/kperreau/goac/pkg/logger"
+	"github.com/kperreau/goac/pkg/project"
+	"github.com/kperreau/goac/pkg/watch"
+	"github.com/spf13/cobra"
+)
+
+// daemonCmd represents the daemon command
+var daemonCmd = &cobra.Command{
+	Use:     "daemon",
+	Example: "goac daemon &",
+	Short:   "Run a daemon keeping projects loaded",
+	Long: `Run a daemon keeping the projects, their packages and their file hashes in memory, updated on file changes.
+Other goac commands run in the same directory get their projects from the daemon while it runs.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) > 0 {
+			return errors.New("bad args number")
+		}
+
+		w := watch.New(pollInterval)
+		defer w.Close()
+
+		return project.RunDaemon(cmd.Context(), w, &project.DaemonOptions{
+			MaxConcurrency: concurrency,
+			Debounce:       debounce,
+			RootPath:       project.RootPath,
+			CachePath:      project.DefaultCachePath,
+		})
+	},
+}
+
+var daemonStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop the daemon",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return project.StopDaemon(cmd.Context(), project.RootPath)
+	},
+}
+
+var daemonStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Check if the daemon is running",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := project.PingDaemon(cmd.Context(), project.RootPath); err != nil {
+			return err
+		}
+		logger.Printf("goac daemon is running\n")
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(daemonCmd)
+	daemonCmd.AddCommand(daemonStopCmd, daemonStatusCmd)
+
+	daemonCmd.Flags().IntVarP(&concurrency, "concurrency", "c", 4, "Max Concurrency")
+	daemonCmd.Flags().DurationVar(&debounce, "debounce", 100*time.Millisecond, "Time without changes waited before hashing changed files")
+	daemonCmd.Flags().DurationVar(&pollInterval, "poll-interval", time.Second, "Interval of the file polling, when inotify is not available")
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/kperreau/goac/pkg/logger"
+	"github.com/kperreau/goac/pkg/project"
+	"github.com/spf13/cobra"
+)
+
+// graphCmd represents the graph command
+var graphCmd = &cobra.Command{
+	Use:     "graph",
+	Example: "goac graph -p api",
+	Short:   "Print the dependencies between projects",
+	Long:    `Print the projects each project depends on, through the Go packages it imports.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) > 0 {
+			return errors.New("bad args number")
+		}
+
+		selector := &project.Selector{
+			Names:   projectsCmd(projects),
+			Tags:    projectsCmd(tags),
+			Paths:   projectsCmd(paths),
+			Exclude: projectsCmd(exclude),
+		}
+		if err := selector.Validate(); err != nil {
+			return err
+		}
+
+		nodes, err := project.LoadGraph(cmd.Context(), &project.Options{
+			MaxConcurrency: concurrency,
+			NoDaemon:       noDaemon,
+			Tracer:         newTracer(),
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, node := range nodes {
+			if !selector.Match(node.Name, node.Path, node.Tags) {
+				continue
+			}
+			logger.Printf("%s %s %s\n", color.BlueString(node.Name), color.YellowString("=>"),
+				strings.Join(node.Dependencies, ", "))
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(graphCmd)
+
+	addSelectorFlags(graphCmd)
+	graphCmd.Flags().IntVarP(&concurrency, "concurrency", "c", 4, "Max Concurrency")
+}
@@ -19,24 +19,33 @@ var listCmd = &cobra.Command{
 			return errors.New("bad args number")
 		}
 
-		listProject, err := project.NewProjectsList(&project.Options{
-			Target:         project.TargetNone,
-			MaxConcurrency: concurrency,
-			ProjectsName:   projectsCmd(projects),
+		listProject, err := project.NewProjectsList(cmd.Context(), &project.Options{
+			Target:           project.TargetNone,
+			MaxConcurrency:   concurrency,
+			ProjectsName:     projectsCmd(projects),
+			Tags:             projectsCmd(tags),
+			Paths:            projectsCmd(paths),
+			Exclude:          projectsCmd(exclude),
+			WithDependents:   withDependents,
+			WithDependencies: withDependencies,
+			NoDaemon:         noDaemon,
+			Tracer:           newTracer(),
 		})
-		if err != nil {
+		if listProject == nil {
 			return err
 		}
 
+		// the projects that loaded are listed, then the load errors are reported
 		listProject.List()
 
-		return nil
+		return err
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(listCmd)
 
-	listCmd.Flags().StringVarP(&projects, "projects", "p", "", "Filter by projects name")
+	addSelectorFlags(listCmd)
+	addGraphFlags(listCmd)
 	listCmd.Flags().IntVarP(&concurrency, "concurrency", "c", 4, "Max Concurrency")
 }
@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+
+	"github.com/kperreau/goac/pkg/hasher"
+	"github.com/kperreau/goac/pkg/logger"
+	"github.com/kperreau/goac/pkg/project"
+	"github.com/spf13/cobra"
+)
+
+// planCmd represents the plan command
+var planCmd = &cobra.Command{
+	Use:     "plan",
+	Example: "goac plan -t build -o plan.json",
+	Short:   "Record the build of the affected projects",
+	Long: `Record the affected projects of a target in a plan, with the reason they are affected,
+their resolved commands and envs, and the hashes of their inputs. Run it later with goac apply.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) > 0 {
+			return errors.New("bad args number")
+		}
+
+		t := project.StringToTarget(target)
+		if t == project.TargetNone {
+			return errors.New("bad argument")
+		}
+
+		algorithm, err := hasher.ParseAlgorithm(hashAlgorithm)
+		if err != nil {
+			return err
+		}
+
+		projectsList, err := project.NewProjectsList(cmd.Context(), &project.Options{
+			Target:           t,
+			DryRun:           true,
+			MaxConcurrency:   concurrency,
+			BinaryCheck:      binaryCheck,
+			Force:            force,
+			DockerIgnore:     dockerignore,
+			ProjectsName:     projectsCmd(projects),
+			Tags:             projectsCmd(tags),
+			Paths:            projectsCmd(paths),
+			Exclude:          projectsCmd(exclude),
+			WithDependents:   withDependents,
+			WithDependencies: withDependencies,
+			HashAlgorithm:    algorithm,
+			SemanticHash:     semanticHash,
+			NoDaemon:         noDaemon,
+			Tracer:           newTracer(),
+		})
+		if err != nil {
+			return err
+		}
+
+		plan := projectsList.Plan()
+		if planOutput == "" {
+			data, err := plan.JSON()
+			if err != nil {
+				return err
+			}
+			logger.Printf("%s", data)
+			return nil
+		}
+		if err := plan.WriteFile(planOutput); err != nil {
+			return err
+		}
+		logger.Infof("Plan: %d affected projects written to %s\n", len(plan.Projects), planOutput)
+		return nil
+	},
+}
+
+// applyCmd represents the apply command
+var applyCmd = &cobra.Command{
+	Use:     "apply <plan>",
+	Example: "goac apply plan.json",
+	Short:   "Build the projects of a plan",
+	Long: `Build the projects recorded by goac plan with the recorded commands and envs.
+Nothing is built if the inputs of a project changed since the plan was made.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("bad args number")
+		}
+
+		reportsArgs, err := reportsCmd(reports)
+		if err != nil {
+			return err
+		}
+
+		plan, err := project.ReadPlan(args[0])
+		if err != nil {
+			return err
+		}
+
+		projectsList, err := project.NewPlanList(cmd.Context(), plan, &project.Options{
+			MaxConcurrency: concurrency,
+			PrintStdout:    stdout,
+			KeepGoing:      keepGoing,
+			NoDaemon:       noDaemon,
+			Tracer:         newTracer(),
+		})
+		if err != nil {
+			// an outdated plan is a failed apply, not a misuse of the command
+			project.LogError(logger.Default(), err)
+			os.Exit(1)
+		}
+
+		err = projectsList.Affected(cmd.Context())
+		if projectsList.Summary() != nil {
+			for _, r := range reportsArgs {
+				if err := projectsList.Summary().WriteReport(r); err != nil {
+					logger.Errorf("error writing %s report: %s\n", r.Format, err)
+				}
+			}
+		}
+		if err != nil {
+			project.LogError(logger.Default(), err)
+			exportTrace(cmd.Context())
+			os.Exit(1)
+		}
+		return nil
+	},
+}
+
+var planOutput string
+
+func init() {
+	rootCmd.AddCommand(planCmd)
+	rootCmd.AddCommand(applyCmd)
+
+	planCmd.Flags().StringVarP(&target, "target", "t", "", "Target")
+	planCmd.Flags().StringVarP(&planOutput, "output", "o", "", "Write the plan to a file instead of stdout")
+	planCmd.Flags().BoolVar(&dockerignore, "dockerignore", true, "Read docker ignore")
+	planCmd.Flags().BoolVar(&binaryCheck, "binarycheck", false, "Affected if binary is missing")
+	planCmd.Flags().BoolVarP(&force, "force", "f", false, "Force build")
+	addSelectorFlags(planCmd)
+	addGraphFlags(planCmd)
+	planCmd.Flags().IntVarP(&concurrency, "concurrency", "c", 4, "Max Concurrency")
+
+	applyCmd.Flags().BoolVar(&stdout, "stdout", false, "Print stdout of exec command")
+	applyCmd.Flags().IntVarP(&concurrency, "concurrency", "c", 4, "Max Concurrency")
+	applyCmd.Flags().BoolVar(&keepGoing, "keep-going", false, "Build every planned project even if some fail")
+	applyCmd.Flags().StringVar(&summaryJSON, "summary-json", "", "Write the summary of the run to a JSON file")
+	applyCmd.Flags().StringArrayVar(&reports, "report", nil, "Write a report of the run as format=path, formats: json,junit,markdown")
+}
@@ -1,9 +1,16 @@
 package cmd
 
 import (
+	"context"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 
+	"github.com/fatih/color"
+	"github.com/kperreau/goac/pkg/logger"
+	"github.com/kperreau/goac/pkg/project"
+	"github.com/kperreau/goac/pkg/trace"
 	"github.com/spf13/cobra"
 )
 
@@ -14,6 +21,14 @@ var rootCmd = &cobra.Command{
 	Long: `GOAC is a CLI library for Go that empowers builds.
 This application is a tool to check if an app is affected by recent change.
 This way it improve build and deployment.`,
+	// errors are printed by Execute with the logger
+	SilenceErrors: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return setupLogger()
+	},
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		exportTrace(cmd.Context())
+	},
 }
 
 func projectsCmd(arg string) []string {
@@ -23,9 +38,113 @@ func projectsCmd(arg string) []string {
 	return strings.Split(arg, ",")
 }
 
+var (
+	tags    string
+	paths   string
+	exclude string
+
+	withDependents   bool
+	withDependencies bool
+)
+
+// addSelectorFlags adds the flags selecting the projects of a command.
+func addSelectorFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&projects, "projects", "p", "", "Filter by projects name, glob or /regexp/")
+	cmd.Flags().StringVar(&tags, "tags", "", "Filter by projects tags")
+	cmd.Flags().StringVar(&paths, "paths", "", "Filter by projects path prefixes")
+	cmd.Flags().StringVar(&exclude, "exclude", "", "Exclude projects by name, glob or /regexp/")
+}
+
+// addGraphFlags adds the flags selecting the dependents and dependencies of the selected projects.
+func addGraphFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&withDependents, "with-dependents", false, "Also select the projects depending on the selected projects")
+	cmd.Flags().BoolVar(&withDependencies, "with-dependencies", false, "Also select the projects the selected projects depend on")
+}
+
+var (
+	hashAlgorithm string
+	semanticHash  bool
+	traceFile     string
+	traceOTLP     string
+	noDaemon      bool
+	tracer        *trace.Tracer
+
+	quiet     bool
+	verbose   int
+	logFormat string
+	noColor   bool
+)
+
+// setupLogger sets the logger of the run from the verbosity and color flags.
+// Colors are disabled by --no-color, by the NO_COLOR environment variable and in the JSON format.
+func setupLogger() error {
+	format, err := logger.ParseFormat(logFormat)
+	if err != nil {
+		return err
+	}
+
+	if noColor || os.Getenv("NO_COLOR") != "" || format == logger.FormatJSON {
+		color.NoColor = true
+	}
+	logger.SetDefault(logger.New(logger.Options{
+		Level:   logger.VerbosityLevel(quiet, verbose),
+		Format:  format,
+		NoColor: color.NoColor,
+	}))
+	return nil
+}
+
+// newTracer returns the tracer of the run when a trace export is requested, nil otherwise.
+func newTracer() *trace.Tracer {
+	if tracer == nil && (traceFile != "" || traceOTLP != "") {
+		tracer = trace.New()
+	}
+	return tracer
+}
+
+// exportTrace exports the trace of the run once, export errors are only warnings.
+func exportTrace(ctx context.Context) {
+	if tracer == nil {
+		return
+	}
+
+	if traceFile != "" {
+		if err := tracer.WriteChrome(traceFile); err != nil {
+			logger.Warnf("error writing trace: %s\n", err)
+		}
+	}
+	if traceOTLP != "" {
+		// the run may have been interrupted, the export is still done
+		if err := tracer.ExportOTLP(context.WithoutCancel(ctx), traceOTLP, "goac"); err != nil {
+			logger.Warnf("error exporting trace: %s\n", err)
+		}
+	}
+	tracer = nil
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&hashAlgorithm, "hash-algorithm", "sha1", "Hash algorithm: sha1,sha256,blake3")
+	rootCmd.PersistentFlags().BoolVar(&semanticHash, "semantic-hash", false, "Ignore comments and formatting when hashing Go files")
+	rootCmd.PersistentFlags().StringVar(&traceFile, "trace", "", "Write a Chrome trace of the run to a file")
+	rootCmd.PersistentFlags().BoolVar(&noDaemon, "no-daemon", false, "Load the projects without the goac daemon, even if it runs")
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "Only print errors to stderr")
+	rootCmd.PersistentFlags().CountVarP(&verbose, "verbose", "v", "Print debug messages to stderr, -vv to trace")
+	rootCmd.PersistentFlags().StringVar(&logFormat, "log-format", "text", "Format of the messages printed to stderr: text,json")
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "Disable colors, also disabled by NO_COLOR")
+	rootCmd.MarkFlagsMutuallyExclusive("quiet", "verbose")
+	rootCmd.PersistentFlags().StringVar(&traceOTLP, "trace-otlp", "", "Export the trace of the run to an OTLP/HTTP endpoint (e.g. http://localhost:4318/v1/traces)")
+}
+
 func Execute() {
-	err := rootCmd.Execute()
+	// cancel running builds on interrupt, their process groups are killed
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err := rootCmd.ExecuteContext(ctx)
 	if err != nil {
+		project.LogError(logger.Default(), err)
+		exportTrace(ctx)
+		stop()
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"errors"
+	"time"
+
+	"github.com/kperreau/goac/pkg/hasher"
+	"github.com/kperreau/goac/pkg/project"
+	"github.com/kperreau/goac/pkg/watch"
+	"github.com/spf13/cobra"
+)
+
+// watchCmd represents the watch command
+var watchCmd = &cobra.Command{
+	Use:     "watch",
+	Example: "goac watch -t build -p api,worker",
+	Short:   "Rebuild affected projects on file changes",
+	Long: `Build the affected projects, then watch the files hashed by each project and rebuild
+the projects whose files change, until interrupted.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) > 0 {
+			return errors.New("bad args number")
+		}
+
+		t := project.StringToTarget(target)
+		if t == project.TargetNone {
+			return errors.New("bad argument")
+		}
+
+		algorithm, err := hasher.ParseAlgorithm(hashAlgorithm)
+		if err != nil {
+			return err
+		}
+
+		projectsList, err := project.NewProjectsList(cmd.Context(), &project.Options{
+			Target:           t,
+			MaxConcurrency:   concurrency,
+			BinaryCheck:      binaryCheck,
+			DockerIgnore:     dockerignore,
+			ProjectsName:     projectsCmd(projects),
+			Tags:             projectsCmd(tags),
+			Paths:            projectsCmd(paths),
+			Exclude:          projectsCmd(exclude),
+			WithDependents:   withDependents,
+			WithDependencies: withDependencies,
+			PrintStdout:      stdout,
+			HashAlgorithm:    algorithm,
+			SemanticHash:     semanticHash,
+			KeepGoing:        true,
+			// the watch keeps its own projects up to date
+			NoDaemon: true,
+		})
+		if err != nil {
+			return err
+		}
+
+		w := watch.New(pollInterval)
+		defer w.Close()
+
+		return projectsList.Watch(cmd.Context(), w, debounce)
+	},
+}
+
+var (
+	debounce     time.Duration
+	pollInterval time.Duration
+)
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+
+	watchCmd.Flags().StringVarP(&target, "target", "t", "", "Target")
+	addSelectorFlags(watchCmd)
+	addGraphFlags(watchCmd)
+	watchCmd.Flags().DurationVar(&debounce, "debounce", project.DefaultWatchDebounce, "Time without changes waited before rebuilding")
+	watchCmd.Flags().DurationVar(&pollInterval, "poll-interval", time.Second, "Interval of the file polling, when inotify is not available")
+	watchCmd.Flags().BoolVar(&stdout, "stdout", false, "Print stdout of exec command")
+	watchCmd.Flags().BoolVar(&dockerignore, "dockerignore", true, "Read docker ignore")
+	watchCmd.Flags().BoolVar(&binaryCheck, "binarycheck", false, "Affected if binary is missing")
+	watchCmd.Flags().IntVarP(&concurrency, "concurrency", "c", 4, "Max Concurrency")
+}
@@ -0,0 +1,51 @@
+package ci
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Job is a CI job building the target of an affected project.
+type Job struct {
+	Project string `json:"project"`
+	Path    string `json:"path"`
+	Target  string `json:"target"`
+	Reason  string `json:"reason,omitempty"`
+	// Flags are the goac flags the affected projects were computed with, like "--hash-algorithm sha256",
+	// the job builds with them so that it hashes the project the same way.
+	Flags string `json:"flags,omitempty"`
+}
+
+// Format is the format of a generated CI pipeline.
+type Format string
+
+const (
+	FormatGitHub Format = "github"
+	FormatGitLab Format = "gitlab"
+)
+
+var Formats = []Format{FormatGitHub, FormatGitLab}
+
+// ParseFormat returns the format named s.
+func ParseFormat(s string) (Format, error) {
+	for _, f := range Formats {
+		if string(f) == s {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("invalid ci format %q, valid formats are: github,gitlab", s)
+}
+
+// command returns the goac command building the job. The project is selected by its path, which,
+// unlike its name, holds no pattern, and is quoted for the shell.
+func (j Job) command() string {
+	command := fmt.Sprintf("goac affected -t %s --paths %s", j.Target, shellQuote(j.Path))
+	if j.Flags != "" {
+		command += " " + j.Flags
+	}
+	return command
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,34 @@
+package ci
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFormat(t *testing.T) {
+	format, err := ParseFormat("gitlab")
+	assert.NoError(t, err)
+	assert.Equal(t, FormatGitLab, format)
+
+	_, err = ParseFormat("jenkins")
+	assert.EqualError(t, err, `invalid ci format "jenkins", valid formats are: github,gitlab`)
+}
+
+func TestJobCommand_QuotesPath(t *testing.T) {
+	j := Job{Project: "it's", Path: "services/it's", Target: "build"}
+
+	assert.Equal(t, `goac affected -t build --paths 'services/it'\''s'`, j.command())
+}
+
+func TestJobCommand_SelectsByPathNotNamePattern(t *testing.T) {
+	j := Job{Project: "auth-*", Path: "services/auth", Target: "build"}
+
+	assert.Equal(t, `goac affected -t build --paths 'services/auth'`, j.command())
+}
+
+func TestJobCommand_PassesFlags(t *testing.T) {
+	j := Job{Project: "auth", Path: "services/auth", Target: "build", Flags: "--hash-algorithm sha256 --force"}
+
+	assert.Equal(t, `goac affected -t build --paths 'services/auth' --hash-algorithm sha256 --force`, j.command())
+}
@@ -0,0 +1,16 @@
+package ci
+
+import "encoding/json"
+
+type githubMatrix struct {
+	Include []Job `json:"include"`
+}
+
+// GitHubMatrix returns a GitHub Actions strategy.matrix with one entry per job, as {"include": [...]}.
+// The include list is empty when there are no jobs.
+func GitHubMatrix(jobs []Job) ([]byte, error) {
+	if jobs == nil {
+		jobs = []Job{}
+	}
+	return json.Marshal(githubMatrix{Include: jobs})
+}
@@ -0,0 +1,32 @@
+package ci
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGitHubMatrix(t *testing.T) {
+	// Arrange
+	jobs := []Job{
+		{Project: "auth", Path: "services/auth", Target: "build", Reason: "files changed"},
+		{Project: "auth", Path: "services/auth", Target: "build-image"},
+	}
+
+	// Act
+	data, err := GitHubMatrix(jobs)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"include": [
+		{"project": "auth", "path": "services/auth", "target": "build", "reason": "files changed"},
+		{"project": "auth", "path": "services/auth", "target": "build-image"}
+	]}`, string(data))
+}
+
+func TestGitHubMatrix_NoJobs(t *testing.T) {
+	data, err := GitHubMatrix(nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, `{"include":[]}`, string(data))
+}
@@ -0,0 +1,83 @@
+package ci
+
+import (
+	"bytes"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// GitLabOptions are the settings of the jobs of a GitLab child pipeline.
+type GitLabOptions struct {
+	// Stage is the stage of the jobs, "build" if empty.
+	Stage string
+	// Image is the docker image of the jobs, the runner default if empty.
+	Image string
+}
+
+type gitlabJob struct {
+	Stage     string            `yaml:"stage"`
+	Image     string            `yaml:"image,omitempty"`
+	Variables map[string]string `yaml:"variables,omitempty"`
+	Script    []string          `yaml:"script"`
+}
+
+// GitLabPipeline returns a GitLab child pipeline with one job per project and target, named target:project.
+// As GitLab rejects a pipeline without jobs, a no-op job is generated when there are no jobs.
+func GitLabPipeline(jobs []Job, opt GitLabOptions) ([]byte, error) {
+	stage := opt.Stage
+	if stage == "" {
+		stage = "build"
+	}
+
+	pipeline := &yaml.Node{Kind: yaml.MappingNode}
+	add := func(key string, value any) error {
+		var node yaml.Node
+		if err := node.Encode(value); err != nil {
+			return err
+		}
+		pipeline.Content = append(pipeline.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, &node)
+		return nil
+	}
+
+	if err := add("stages", []string{stage}); err != nil {
+		return nil, err
+	}
+
+	if len(jobs) == 0 {
+		if err := add("no-affected-projects", gitlabJob{
+			Stage:  stage,
+			Image:  opt.Image,
+			Script: []string{`echo "No affected projects"`},
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, j := range jobs {
+		err := add(fmt.Sprintf("%s:%s", j.Target, j.Project), gitlabJob{
+			Stage: stage,
+			Image: opt.Image,
+			Variables: map[string]string{
+				"GOAC_PROJECT": j.Project,
+				"GOAC_PATH":    j.Path,
+				"GOAC_TARGET":  j.Target,
+			},
+			Script: []string{j.command()},
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var b bytes.Buffer
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err := enc.Encode(pipeline); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
@@ -0,0 +1,55 @@
+package ci
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGitLabPipeline(t *testing.T) {
+	// Arrange
+	jobs := []Job{
+		{Project: "users", Path: "services/users", Target: "build"},
+		{Project: "auth", Path: "services/auth", Target: "build"},
+	}
+
+	// Act
+	data, err := GitLabPipeline(jobs, GitLabOptions{Image: "golang:1.22"})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, `stages:
+  - build
+build:users:
+  stage: build
+  image: golang:1.22
+  variables:
+    GOAC_PATH: services/users
+    GOAC_PROJECT: users
+    GOAC_TARGET: build
+  script:
+    - goac affected -t build --paths 'services/users'
+build:auth:
+  stage: build
+  image: golang:1.22
+  variables:
+    GOAC_PATH: services/auth
+    GOAC_PROJECT: auth
+    GOAC_TARGET: build
+  script:
+    - goac affected -t build --paths 'services/auth'
+`, string(data))
+}
+
+func TestGitLabPipeline_NoJobs(t *testing.T) {
+	data, err := GitLabPipeline(nil, GitLabOptions{Stage: "compile"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, `stages:
+  - compile
+no-affected-projects:
+  stage: compile
+  script:
+    - echo "No affected projects"
+`, string(data))
+}
@@ -0,0 +1,35 @@
+package flock
+
+import (
+	"fmt"
+	"os"
+)
+
+// Lock is an advisory lock held on a file, shared between goac processes.
+type Lock struct {
+	file *os.File
+}
+
+// Acquire blocks until the exclusive lock on path is held. The lock file is created if needed.
+func Acquire(path string) (*Lock, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("error opening lock file %s: %w", path, err)
+	}
+
+	if err := lock(file); err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("error locking %s: %w", path, err)
+	}
+
+	return &Lock{file: file}, nil
+}
+
+// Release unlocks and closes the lock file.
+func (l *Lock) Release() error {
+	if err := unlock(l.file); err != nil {
+		_ = l.file.Close()
+		return err
+	}
+	return l.file.Close()
+}
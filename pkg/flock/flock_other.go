@@ -0,0 +1,10 @@
+//go:build !unix
+
+package flock
+
+import "os"
+
+// Advisory locks are only supported on unix systems, writes stay atomic but are not serialized.
+func lock(*os.File) error { return nil }
+
+func unlock(*os.File) error { return nil }
@@ -0,0 +1,56 @@
+package flock
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAcquire_CreatesLockFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "entry.lock")
+
+	l, err := Acquire(path)
+
+	assert.NoError(t, err)
+	assert.FileExists(t, path)
+	assert.NoError(t, l.Release())
+}
+
+func TestAcquire_InvalidPath(t *testing.T) {
+	l, err := Acquire(filepath.Join(t.TempDir(), "not-exist", "entry.lock"))
+
+	assert.Error(t, err)
+	assert.Nil(t, l)
+}
+
+func TestAcquire_SerializesHolders(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "entry.lock")
+
+	first, err := Acquire(path)
+	assert.NoError(t, err)
+
+	var mu sync.Mutex
+	var order []string
+	done := make(chan struct{})
+	go func() {
+		second, err := Acquire(path)
+		assert.NoError(t, err)
+		mu.Lock()
+		order = append(order, "second")
+		mu.Unlock()
+		assert.NoError(t, second.Release())
+		close(done)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	mu.Lock()
+	order = append(order, "first")
+	mu.Unlock()
+	assert.NoError(t, first.Release())
+	<-done
+
+	assert.Equal(t, []string{"first", "second"}, order)
+}
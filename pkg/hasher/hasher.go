@@ -2,6 +2,7 @@ package hasher
 
 import (
 	"crypto/sha1"
+	"crypto/sha256"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -11,36 +12,73 @@ import (
 	"sort"
 	"strings"
 	"sync"
+
+	"lukechampine.com/blake3"
 )
 
+// FileSum is the content hash of a single file.
+type FileSum struct {
+	Path string
+	Sum  []byte
+}
+
 func Files(files []string, hashPool *sync.Pool) (string, error) {
-	h := hashPool.Get().(hash.Hash)
-	defer hashPool.Put(h)
-	h.Reset()
+	sums, err := Sums(files, hashPool)
+	if err != nil {
+		return "", err
+	}
+
+	return Combine(sums, hashPool, nil), nil
+}
 
+// Sums hashes the content of each file, sorted by path.
+func Sums(files []string, hashPool *sync.Pool) ([]FileSum, error) {
 	hf := hashPool.Get().(hash.Hash)
 	defer hashPool.Put(hf)
 
 	files = append([]string(nil), files...)
 	sort.Strings(files)
+	sums := make([]FileSum, 0, len(files))
 	for _, file := range files {
 		if strings.Contains(file, "\n") {
-			return "", errors.New("filenames with newlines are not supported")
+			return nil, errors.New("filenames with newlines are not supported")
 		}
 		r, err := os.Open(file)
 		if err != nil {
-			return "", err
+			return nil, err
 		}
 
 		hf.Reset()
 		_, err = io.Copy(hf, r)
 		r.Close()
 		if err != nil {
-			return "", err
+			return nil, err
 		}
-		fmt.Fprintf(h, "%x  %s\n", hf.Sum(nil), file)
+		sums = append(sums, FileSum{Path: file, Sum: hf.Sum(nil)})
 	}
-	return hex.EncodeToString(h.Sum(nil)), nil
+	return sums, nil
+}
+
+// Combine hashes a list of file sums into a single hash.
+// The optional rename func changes the path recorded for each file, sums are then re-sorted by the new path.
+func Combine(sums []FileSum, hashPool *sync.Pool, rename func(string) string) string {
+	h := hashPool.Get().(hash.Hash)
+	defer hashPool.Put(h)
+	h.Reset()
+
+	if rename != nil {
+		renamed := make([]FileSum, 0, len(sums))
+		for _, sum := range sums {
+			renamed = append(renamed, FileSum{Path: rename(sum.Path), Sum: sum.Sum})
+		}
+		sort.SliceStable(renamed, func(i, j int) bool { return renamed[i].Path < renamed[j].Path })
+		sums = renamed
+	}
+
+	for _, sum := range sums {
+		fmt.Fprintf(h, "%x  %s\n", sum.Sum, sum.Path)
+	}
+	return hex.EncodeToString(h.Sum(nil))
 }
 
 func WithPool(hashPool *sync.Pool, s string) (string, error) {
@@ -54,7 +92,52 @@ func WithPool(hashPool *sync.Pool, s string) (string, error) {
 	return hex.EncodeToString(h.Sum(nil)), nil
 }
 
+// Algorithm is a supported hash algorithm.
+type Algorithm string
+
+const (
+	SHA1   Algorithm = "sha1"
+	SHA256 Algorithm = "sha256"
+	BLAKE3 Algorithm = "blake3"
+)
+
+var Algorithms = []Algorithm{SHA1, SHA256, BLAKE3}
+
+func (a Algorithm) String() string { return string(a) }
+
+// ParseAlgorithm returns the algorithm matching s, SHA1 if s is empty.
+func ParseAlgorithm(s string) (Algorithm, error) {
+	if s == "" {
+		return SHA1, nil
+	}
+
+	for _, algorithm := range Algorithms {
+		if string(algorithm) == strings.ToLower(s) {
+			return algorithm, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown hash algorithm %q, valid values are: sha1,sha256,blake3", s)
+}
+
+// NewPool returns a pool of SHA-1 hashes.
 func NewPool() *sync.Pool {
+	return NewAlgorithmPool(SHA1)
+}
+
+// NewAlgorithmPool returns a pool of hashes of the given algorithm, SHA-1 if unknown.
+func NewAlgorithmPool(algorithm Algorithm) *sync.Pool {
+	switch algorithm {
+	case SHA256:
+		return &sync.Pool{
+			New: func() any { return sha256.New() },
+		}
+	case BLAKE3:
+		return &sync.Pool{
+			New: func() any { return blake3.New(32, nil) },
+		}
+	}
+
 	return &sync.Pool{
 		New: func() any { return sha1.New() },
 	}
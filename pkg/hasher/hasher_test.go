@@ -4,6 +4,7 @@ import (
 	"crypto/sha1"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -68,3 +69,80 @@ func TestNewPool_ReturnsSyncPoolWithSha1New(t *testing.T) {
 	assert.NotNil(t, pool.New)
 	assert.IsType(t, sha1.New(), pool.New())
 }
+
+func TestCombine_RenameResortsFiles(t *testing.T) {
+	hashPool := NewPool()
+	sums := []FileSum{
+		{Path: "a/main.go", Sum: []byte{1}},
+		{Path: "pkg/lib.go", Sum: []byte{2}},
+	}
+	moved := []FileSum{
+		{Path: "pkg/lib.go", Sum: []byte{2}},
+		{Path: "z/main.go", Sum: []byte{1}},
+	}
+	rename := func(prefix string) func(string) string {
+		return func(path string) string {
+			if strings.HasPrefix(path, prefix) {
+				return "{{project-path}}/" + strings.TrimPrefix(path, prefix)
+			}
+			return path
+		}
+	}
+
+	result := Combine(sums, hashPool, rename("a/"))
+	movedResult := Combine(moved, hashPool, rename("z/"))
+
+	assert.Equal(t, result, movedResult)
+	assert.NotEqual(t, Combine(sums, hashPool, nil), Combine(moved, hashPool, nil))
+}
+
+func TestSums_SortedByPath(t *testing.T) {
+	sums, err := Sums([]string{"hasher_test.go", "hasher.go"}, NewPool())
+
+	assert.NoError(t, err)
+	assert.Len(t, sums, 2)
+	assert.Equal(t, "hasher.go", sums[0].Path)
+	assert.Equal(t, "hasher_test.go", sums[1].Path)
+}
+
+func TestParseAlgorithm_DefaultSHA1(t *testing.T) {
+	algorithm, err := ParseAlgorithm("")
+
+	assert.NoError(t, err)
+	assert.Equal(t, SHA1, algorithm)
+}
+
+func TestParseAlgorithm_CaseInsensitive(t *testing.T) {
+	algorithm, err := ParseAlgorithm("BLAKE3")
+
+	assert.NoError(t, err)
+	assert.Equal(t, BLAKE3, algorithm)
+}
+
+func TestParseAlgorithm_Unknown(t *testing.T) {
+	_, err := ParseAlgorithm("md5")
+
+	assert.Error(t, err)
+}
+
+func TestNewAlgorithmPool_HashSizes(t *testing.T) {
+	tests := map[Algorithm]int{
+		SHA1:   40,
+		SHA256: 64,
+		BLAKE3: 64,
+	}
+
+	for algorithm, size := range tests {
+		result, err := WithPool(NewAlgorithmPool(algorithm), "test string")
+
+		assert.NoError(t, err)
+		assert.Len(t, result, size, algorithm.String())
+	}
+}
+
+func TestNewAlgorithmPool_DifferentAlgorithmsDifferentHashes(t *testing.T) {
+	sha256Hash, _ := WithPool(NewAlgorithmPool(SHA256), "test string")
+	blake3Hash, _ := WithPool(NewAlgorithmPool(BLAKE3), "test string")
+
+	assert.NotEqual(t, sha256Hash, blake3Hash)
+}
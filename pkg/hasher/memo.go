@@ -0,0 +1,165 @@
+package hasher
+
+import (
+	"errors"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// Memo memoizes file and directory sums for a whole run, so that files shared by
+// several projects are read and hashed once. Reads are done by a bounded number of workers.
+type Memo struct {
+	hashPool *sync.Pool
+	semantic bool
+	root     string
+	sem      chan struct{}
+	files    sync.Map // file path => *memoEntry
+	dirs     sync.Map // directory key => *memoEntry
+}
+
+type memoEntry struct {
+	once sync.Once
+	sum  []byte
+	sums []FileSum
+	err  error
+}
+
+// NewMemo returns a memo hashing files with the pool hashes, reading at most workers files at once.
+func NewMemo(hashPool *sync.Pool, workers int) *Memo {
+	if workers < 1 {
+		workers = 1
+	}
+
+	return &Memo{
+		hashPool: hashPool,
+		sem:      make(chan struct{}, workers),
+	}
+}
+
+// Sums hashes the content of each file, sorted by path. Files already hashed during the run are not read again.
+func (m *Memo) Sums(files []string) ([]FileSum, error) {
+	files = append([]string(nil), files...)
+	sort.Strings(files)
+
+	// the names are checked before hashing, no file is left being read on error
+	if slices.ContainsFunc(files, func(file string) bool { return strings.Contains(file, "\n") }) {
+		return nil, errors.New("filenames with newlines are not supported")
+	}
+
+	// at most workers goroutines hash the files, instead of one per file waiting for the others
+	sums := make([]FileSum, len(files))
+	eg := errgroup.Group{}
+	eg.SetLimit(cap(m.sem))
+	for i, file := range files {
+		eg.Go(func() error {
+			sum, err := m.file(file)
+			if err != nil {
+				return err
+			}
+			sums[i] = FileSum{Path: file, Sum: sum}
+			return nil
+		})
+	}
+
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+
+	return sums, nil
+}
+
+// Dir returns the sums of the files listed by list, memoized by key.
+// The key must identify the directory and the rule used to list its files.
+func (m *Memo) Dir(key string, list func() ([]string, error)) ([]FileSum, error) {
+	value, _ := m.dirs.LoadOrStore(key, &memoEntry{})
+	entry := value.(*memoEntry)
+	entry.once.Do(func() {
+		files, err := list()
+		if err != nil {
+			entry.err = err
+			return
+		}
+		entry.sums, entry.err = m.Sums(files)
+	})
+
+	return entry.sums, entry.err
+}
+
+// Semantic enables the semantic hashing of Go files, see WriteSemantic.
+// Go files that do not parse and other files are still hashed byte for byte.
+func (m *Memo) Semantic(enabled bool) *Memo {
+	m.semantic = enabled
+	return m
+}
+
+// Root reads the relative paths of the files from dir, the sums keep the paths as given.
+func (m *Memo) Root(dir string) *Memo {
+	m.root = dir
+	return m
+}
+
+func (m *Memo) file(file string) ([]byte, error) {
+	value, _ := m.files.LoadOrStore(file, &memoEntry{})
+	entry := value.(*memoEntry)
+	entry.once.Do(func() {
+		m.sem <- struct{}{}        // acquire
+		defer func() { <-m.sem }() // release
+		entry.sum, entry.err = m.read(file)
+	})
+
+	return entry.sum, entry.err
+}
+
+func (m *Memo) read(file string) ([]byte, error) {
+	h := m.hashPool.Get().(hash.Hash)
+	defer m.hashPool.Put(h)
+	h.Reset()
+
+	if m.root != "" && !filepath.IsAbs(file) {
+		file = filepath.Join(m.root, file)
+	}
+
+	if m.semantic && filepath.Ext(file) == ".go" {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if err := WriteSemantic(h, src); err != nil {
+			h.Reset()
+			h.Write(src)
+		}
+		return h.Sum(nil), nil
+	}
+
+	r, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	if _, err := io.Copy(h, r); err != nil {
+		return nil, err
+	}
+
+	return h.Sum(nil), nil
+}
+
+// Forget drops the sums of the files and the memoized directories, so that they are computed again.
+// The other files are not read again.
+func (m *Memo) Forget(files ...string) {
+	for _, file := range files {
+		m.files.Delete(file)
+	}
+	m.dirs.Range(func(key, _ any) bool {
+		m.dirs.Delete(key)
+		return true
+	})
+}
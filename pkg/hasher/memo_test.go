@@ -0,0 +1,158 @@
+package hasher
+
+import (
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoSums_SameResultAsFiles(t *testing.T) {
+	files, err := listFiles("../project")
+	assert.NoError(t, err)
+	hashPool := NewPool()
+
+	sums, err := NewMemo(hashPool, 4).Sums(files)
+	assert.NoError(t, err)
+
+	expected, err := Files(files, hashPool)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, Combine(sums, hashPool, nil))
+}
+
+func TestMemoSums_ReadsFilesOnce(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file.go")
+	assert.NoError(t, os.WriteFile(file, []byte("package file"), 0o644))
+	memo := NewMemo(NewPool(), 2)
+
+	first, err := memo.Sums([]string{file})
+	assert.NoError(t, err)
+
+	// the file changes during the run, the memoized sum is kept
+	assert.NoError(t, os.WriteFile(file, []byte("package changed"), 0o644))
+	second, err := memo.Sums([]string{file})
+	assert.NoError(t, err)
+
+	assert.Equal(t, first, second)
+}
+
+func TestMemoSums_FileNotFound(t *testing.T) {
+	sums, err := NewMemo(NewPool(), 2).Sums([]string{"not-found.go"})
+
+	assert.Error(t, err)
+	assert.Nil(t, sums)
+}
+
+func TestMemoSums_NewlineInFilename(t *testing.T) {
+	_, err := NewMemo(NewPool(), 2).Sums([]string{"file\n.go"})
+
+	assert.Error(t, err)
+}
+
+func TestMemoSums_NewlineInFilenameHashesNoFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "a.go")
+	assert.NoError(t, os.WriteFile(file, []byte("package a"), 0o644))
+	memo := NewMemo(NewPool(), 2)
+
+	_, err := memo.Sums([]string{file, file + "\n.go"})
+
+	assert.Error(t, err)
+	_, hashed := memo.files.Load(file)
+	assert.False(t, hashed)
+}
+
+func TestMemoDir_ListsOncePerKey(t *testing.T) {
+	memo := NewMemo(NewPool(), 2)
+	var calls atomic.Int32
+	list := func() ([]string, error) {
+		calls.Add(1)
+		return []string{"memo.go", "hasher.go"}, nil
+	}
+
+	first, err1 := memo.Dir("key", list)
+	second, err2 := memo.Dir("key", list)
+	_, err3 := memo.Dir("other-key", list)
+
+	assert.NoError(t, err1)
+	assert.NoError(t, err2)
+	assert.NoError(t, err3)
+	assert.Equal(t, first, second)
+	assert.Equal(t, "hasher.go", first[0].Path)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestMemoSums_SemanticIgnoresGoFormatting(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.go")
+	b := filepath.Join(dir, "b.go")
+	assert.NoError(t, os.WriteFile(a, []byte("package a\n\n// comment\nfunc f() int { return 1 }\n"), 0o644))
+	assert.NoError(t, os.WriteFile(b, []byte("package a\nfunc f() int {\n\treturn 1\n}\n"), 0o644))
+
+	semantic, err := NewMemo(NewPool(), 2).Semantic(true).Sums([]string{a, b})
+	assert.NoError(t, err)
+	assert.Equal(t, semantic[0].Sum, semantic[1].Sum)
+
+	raw, err := NewMemo(NewPool(), 2).Sums([]string{a, b})
+	assert.NoError(t, err)
+	assert.NotEqual(t, raw[0].Sum, raw[1].Sum)
+}
+
+func TestMemoSums_SemanticKeepsBytesForOtherFiles(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.txt")
+	b := filepath.Join(dir, "b.go")
+	assert.NoError(t, os.WriteFile(a, []byte("package a"), 0o644))
+	assert.NoError(t, os.WriteFile(b, []byte("not go"), 0o644))
+	hashPool := NewPool()
+
+	sums, err := NewMemo(hashPool, 2).Semantic(true).Sums([]string{a, b})
+	assert.NoError(t, err)
+
+	expected, err := Sums([]string{a, b}, hashPool)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, sums)
+}
+
+func TestMemoForget_HashesForgottenFilesAgain(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	changed, kept := filepath.Join(dir, "changed.go"), filepath.Join(dir, "kept.go")
+	assert.NoError(t, os.WriteFile(changed, []byte("package changed"), 0o644))
+	assert.NoError(t, os.WriteFile(kept, []byte("package kept"), 0o644))
+	memo := NewMemo(NewPool(), 2)
+	var calls atomic.Int32
+	list := func() ([]string, error) {
+		calls.Add(1)
+		return []string{changed, kept}, nil
+	}
+	first, err := memo.Dir("dir", list)
+	assert.NoError(t, err)
+
+	// Act
+	assert.NoError(t, os.WriteFile(changed, []byte("package changed2"), 0o644))
+	assert.NoError(t, os.WriteFile(kept, []byte("package kept2"), 0o644))
+	memo.Forget(changed)
+	second, err := memo.Dir("dir", list)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), calls.Load())
+	assert.NotEqual(t, first[0].Sum, second[0].Sum)
+	assert.Equal(t, first[1].Sum, second[1].Sum)
+}
+
+func TestMemoRoot_ReadsRelativeFilesFromRoot(t *testing.T) {
+	root := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "file.go"), []byte("package file"), 0o644))
+	hashPool := NewPool()
+
+	sums, err := NewMemo(hashPool, 1).Root(root).Sums([]string{"file.go"})
+	assert.NoError(t, err)
+
+	expected, err := Sums([]string{filepath.Join(root, "file.go")}, hashPool)
+	assert.NoError(t, err)
+	assert.Equal(t, "file.go", sums[0].Path)
+	assert.Equal(t, expected[0].Sum, sums[0].Sum)
+}
@@ -0,0 +1,191 @@
+package hasher
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/parser"
+	"go/token"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	posType          = reflect.TypeOf(token.NoPos)
+	commentGroupType = reflect.TypeOf(&ast.CommentGroup{})
+	objectType       = reflect.TypeOf(&ast.Object{})
+	scopeType        = reflect.TypeOf(&ast.Scope{})
+)
+
+// WriteSemantic writes a normalized form of a Go source file to w: the syntax tree without
+// positions, comments and formatting, with literals written by value and imports sorted.
+// Build constraints, //go: and //export directives and cgo preambles are kept.
+// It returns an error if src is not a valid Go file.
+func WriteSemantic(w io.Writer, src []byte) error {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return err
+	}
+
+	s := &semanticWriter{w: w}
+	s.writeDirectives(file)
+	s.writeImports(file)
+
+	s.printf("package ")
+	s.node(reflect.ValueOf(file.Name))
+	for _, decl := range file.Decls {
+		if isImportDecl(decl) {
+			continue
+		}
+		s.node(reflect.ValueOf(&decl).Elem())
+	}
+
+	return s.err
+}
+
+type semanticWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (s *semanticWriter) printf(format string, args ...any) {
+	if s.err != nil {
+		return
+	}
+	_, s.err = fmt.Fprintf(s.w, format, args...)
+}
+
+// writeDirectives writes the directives with the index of the declaration following them,
+// so that moving a directive to another declaration changes the hash.
+func (s *semanticWriter) writeDirectives(file *ast.File) {
+	for _, group := range file.Comments {
+		decl := sort.Search(len(file.Decls), func(i int) bool { return file.Decls[i].Pos() > group.End() })
+		for _, c := range group.List {
+			if isDirective(c.Text) {
+				s.printf("directive %d %q\n", decl, strings.TrimSpace(c.Text))
+			}
+		}
+	}
+}
+
+func isDirective(comment string) bool {
+	return strings.HasPrefix(comment, "//go:") ||
+		strings.HasPrefix(comment, "//export ") ||
+		strings.HasPrefix(comment, "//line ") ||
+		strings.HasPrefix(comment, "// +build ")
+}
+
+// writeImports writes the imports sorted by path, with the cgo preamble of import "C".
+func (s *semanticWriter) writeImports(file *ast.File) {
+	imports := make([]string, 0, len(file.Imports))
+	for _, decl := range file.Decls {
+		if !isImportDecl(decl) {
+			continue
+		}
+		gen := decl.(*ast.GenDecl)
+		for _, spec := range gen.Specs {
+			spec := spec.(*ast.ImportSpec)
+			path, _ := strconv.Unquote(spec.Path.Value)
+			name := ""
+			if spec.Name != nil {
+				name = spec.Name.Name
+			}
+
+			line := fmt.Sprintf("import %q %q", name, path)
+			if path == "C" {
+				line += fmt.Sprintf(" %q", cgoPreamble(gen, spec))
+			}
+			imports = append(imports, line)
+		}
+	}
+	sort.Strings(imports)
+
+	for _, line := range imports {
+		s.printf("%s\n", line)
+	}
+}
+
+func isImportDecl(decl ast.Decl) bool {
+	gen, ok := decl.(*ast.GenDecl)
+	return ok && gen.Tok == token.IMPORT
+}
+
+func cgoPreamble(gen *ast.GenDecl, spec *ast.ImportSpec) string {
+	doc := spec.Doc
+	if doc == nil && !gen.Lparen.IsValid() {
+		doc = gen.Doc
+	}
+	if doc == nil {
+		return ""
+	}
+
+	var b strings.Builder
+	for _, c := range doc.List {
+		b.WriteString(c.Text)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// node writes a syntax tree node by reflection, skipping positions, comments and resolved objects.
+func (s *semanticWriter) node(v reflect.Value) {
+	if s.err != nil {
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Interface, reflect.Pointer:
+		if v.IsNil() {
+			s.printf("nil;")
+			return
+		}
+		if v.Kind() == reflect.Interface {
+			s.node(v.Elem())
+			return
+		}
+		if lit, ok := v.Interface().(*ast.BasicLit); ok {
+			s.printf("%s(%s);", lit.Kind, literalValue(lit))
+			return
+		}
+		s.node(v.Elem())
+	case reflect.Struct:
+		s.printf("%s{", v.Type().Name())
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			switch field.Type {
+			case posType, commentGroupType, objectType, scopeType:
+				continue
+			}
+			s.printf("%s:", field.Name)
+			s.node(v.Field(i))
+		}
+		s.printf("}")
+	case reflect.Slice:
+		s.printf("[%d", v.Len())
+		for i := 0; i < v.Len(); i++ {
+			s.printf(",")
+			s.node(v.Index(i))
+		}
+		s.printf("]")
+	case reflect.String:
+		s.printf("%q;", v.String())
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		s.printf("%v;", v.Interface())
+	default:
+		s.err = fmt.Errorf("unsupported syntax tree value %s", v.Type())
+	}
+}
+
+// literalValue returns the exact value of a literal, so that 0755 and 0o755 or "a" and `a` are equal.
+func literalValue(lit *ast.BasicLit) string {
+	value := constant.MakeFromLiteral(lit.Value, lit.Kind, 0)
+	if value.Kind() == constant.Unknown {
+		return lit.Value
+	}
+	return value.ExactString()
+}
@@ -0,0 +1,85 @@
+package hasher
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func semantic(t *testing.T, src string) string {
+	var b bytes.Buffer
+	assert.NoError(t, WriteSemantic(&b, []byte(src)))
+	return b.String()
+}
+
+func TestWriteSemantic_IgnoresCommentsAndFormatting(t *testing.T) {
+	a := `package a
+
+import (
+	"os"
+	"fmt"
+)
+
+// Print prints.
+func Print() {
+	fmt.Println("hello", os.Args) // trailing
+}
+`
+	b := `package a
+
+import "fmt"
+import "os"
+
+func Print() { fmt.Println(
+	"hello",
+	os.Args,
+) }
+`
+
+	assert.Equal(t, semantic(t, a), semantic(t, b))
+}
+
+func TestWriteSemantic_NormalizesLiterals(t *testing.T) {
+	assert.Equal(t,
+		semantic(t, "package a\nconst mode, s, f = 0755, \"a\\tb\", 1.50\n"),
+		semantic(t, "package a\nconst mode, s, f = 0o755, `a\tb`, 1.5\n"),
+	)
+}
+
+func TestWriteSemantic_DetectsCodeChanges(t *testing.T) {
+	assert.NotEqual(t,
+		semantic(t, "package a\nfunc f() int { return 1 }\n"),
+		semantic(t, "package a\nfunc f() int { return 2 }\n"),
+	)
+	assert.NotEqual(t,
+		semantic(t, "package a\nfunc f(a, b int) int { return a - b }\n"),
+		semantic(t, "package a\nfunc f(b, a int) int { return a - b }\n"),
+	)
+}
+
+func TestWriteSemantic_KeepsDirectives(t *testing.T) {
+	base := "package a\n\nfunc f() {}\n\nfunc g() {}\n"
+
+	assert.NotEqual(t, semantic(t, "//go:build linux\n\n"+base), semantic(t, base))
+	assert.NotEqual(t,
+		semantic(t, "package a\n\n//go:noinline\nfunc f() {}\n\nfunc g() {}\n"),
+		semantic(t, "package a\n\nfunc f() {}\n\n//go:noinline\nfunc g() {}\n"),
+	)
+	assert.NotEqual(t,
+		semantic(t, "package a\n\n//export f\nfunc f() {}\n"),
+		semantic(t, "package a\n\nfunc f() {}\n"),
+	)
+}
+
+func TestWriteSemantic_KeepsCgoPreamble(t *testing.T) {
+	assert.NotEqual(t,
+		semantic(t, "package a\n\n// int add(int a, int b) { return a + b; }\nimport \"C\"\n"),
+		semantic(t, "package a\n\n// int add(int a, int b) { return a - b; }\nimport \"C\"\n"),
+	)
+}
+
+func TestWriteSemantic_InvalidSource(t *testing.T) {
+	var b bytes.Buffer
+	assert.Error(t, WriteSemantic(&b, []byte("package a\nfunc {")))
+}
@@ -0,0 +1,190 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// Level is the verbosity of a logger, the messages of a higher level are not printed.
+type Level int
+
+const (
+	LevelError Level = iota
+	LevelWarn
+	LevelInfo
+	LevelDebug
+	LevelTrace
+)
+
+var levelNames = map[Level]string{
+	LevelError: "error",
+	LevelWarn:  "warn",
+	LevelInfo:  "info",
+	LevelDebug: "debug",
+	LevelTrace: "trace",
+}
+
+func (l Level) String() string {
+	return levelNames[l]
+}
+
+// VerbosityLevel returns the level of the quiet flag and of the number of verbose flags.
+func VerbosityLevel(quiet bool, verbose int) Level {
+	if quiet {
+		return LevelError
+	}
+	return min(LevelInfo+Level(verbose), LevelTrace)
+}
+
+// Format is the format of the messages written to stderr.
+type Format string
+
+const (
+	FormatText Format = "text"
+	FormatJSON Format = "json"
+)
+
+func ParseFormat(s string) (Format, error) {
+	switch Format(s) {
+	case FormatText, FormatJSON:
+		return Format(s), nil
+	}
+	return "", fmt.Errorf("unknown log format %q, valid formats are: %s,%s", s, FormatText, FormatJSON)
+}
+
+// Options configures a Logger.
+type Options struct {
+	Level  Level
+	Format Format
+	// NoColor disables the colors of the messages levels.
+	NoColor bool
+	// Stdout receives the data output and Stderr the messages, os.Stdout and os.Stderr if nil.
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// Logger prints the data output of goac to stdout, and its messages to stderr by level.
+// It is safe for concurrent use.
+type Logger struct {
+	mu  sync.Mutex
+	opt Options
+}
+
+func New(opt Options) *Logger {
+	if opt.Format == "" {
+		opt.Format = FormatText
+	}
+	return &Logger{opt: opt}
+}
+
+// Discard returns a logger printing nothing.
+func Discard() *Logger {
+	return New(Options{Level: LevelError - 1, Stdout: io.Discard, Stderr: io.Discard})
+}
+
+// Printf prints data output to stdout, whatever the level.
+func (l *Logger) Printf(format string, a ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, _ = fmt.Fprintf(l.stdout(), format, a...)
+}
+
+func (l *Logger) Errorf(format string, a ...any) { l.log(LevelError, nil, format, a...) }
+func (l *Logger) Warnf(format string, a ...any)  { l.log(LevelWarn, nil, format, a...) }
+func (l *Logger) Infof(format string, a ...any)  { l.log(LevelInfo, nil, format, a...) }
+func (l *Logger) Debugf(format string, a ...any) { l.log(LevelDebug, nil, format, a...) }
+func (l *Logger) Tracef(format string, a ...any) { l.log(LevelTrace, nil, format, a...) }
+
+// ErrorWith prints an error message with fields describing it, they are added to the JSON entries.
+func (l *Logger) ErrorWith(fields map[string]string, format string, a ...any) {
+	l.log(LevelError, fields, format, a...)
+}
+
+// Enabled reports if the messages of the level are printed.
+func (l *Logger) Enabled(level Level) bool {
+	return level <= l.opt.Level
+}
+
+// levelColors are the colors of the messages of each level in the text format.
+var levelColors = map[Level]*color.Color{
+	LevelError: color.New(color.FgRed),
+	LevelWarn:  color.New(color.FgYellow),
+	LevelDebug: color.New(color.FgHiBlack),
+	LevelTrace: color.New(color.FgHiBlack),
+}
+
+// ansiColors matches the color sequences in messages, they are removed in the JSON format.
+var ansiColors = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+func (l *Logger) log(level Level, fields map[string]string, format string, a ...any) {
+	if !l.Enabled(level) {
+		return
+	}
+	msg := fmt.Sprintf(format, a...)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.opt.Format == FormatJSON {
+		entry := map[string]string{}
+		for key, value := range fields {
+			entry[key] = value
+		}
+		entry["time"] = time.Now().Format(time.RFC3339)
+		entry["level"] = level.String()
+		entry["msg"] = strings.TrimSuffix(ansiColors.ReplaceAllString(msg, ""), "\n")
+		_ = json.NewEncoder(l.stderr()).Encode(entry)
+		return
+	}
+
+	if c, ok := levelColors[level]; ok && !l.opt.NoColor {
+		msg = c.Sprint(msg)
+	}
+	_, _ = fmt.Fprint(l.stderr(), msg)
+}
+
+func (l *Logger) stdout() io.Writer {
+	if l.opt.Stdout == nil {
+		return os.Stdout
+	}
+	return l.opt.Stdout
+}
+
+func (l *Logger) stderr() io.Writer {
+	if l.opt.Stderr == nil {
+		return os.Stderr
+	}
+	return l.opt.Stderr
+}
+
+var std = New(Options{Level: LevelInfo})
+
+// Default returns the logger of the package functions.
+func Default() *Logger {
+	return std
+}
+
+// SetDefault replaces the logger of the package functions, it must be called before logging.
+func SetDefault(l *Logger) {
+	std = l
+}
+
+func Printf(format string, a ...any) { std.Printf(format, a...) }
+func Errorf(format string, a ...any) { std.Errorf(format, a...) }
+func Warnf(format string, a ...any)  { std.Warnf(format, a...) }
+func Infof(format string, a ...any)  { std.Infof(format, a...) }
+func Debugf(format string, a ...any) { std.Debugf(format, a...) }
+func Tracef(format string, a ...any) { std.Tracef(format, a...) }
+
+func BoldGreen(s string) string {
+	c := color.New(color.Bold).Add(color.FgGreen).SprintFunc()
+	return c(s)
+}
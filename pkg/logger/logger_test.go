@@ -0,0 +1,126 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestLogger(opt Options) (*Logger, *bytes.Buffer, *bytes.Buffer) {
+	var stdout, stderr bytes.Buffer
+	opt.Stdout, opt.Stderr = &stdout, &stderr
+	return New(opt), &stdout, &stderr
+}
+
+func TestLogger_DataToStdoutMessagesToStderr(t *testing.T) {
+	// Arrange
+	l, stdout, stderr := newTestLogger(Options{Level: LevelInfo, NoColor: true})
+
+	// Act
+	l.Printf("api => services/api\n")
+	l.Infof("Building api...\n")
+	l.Warnf("retry\n")
+	l.Errorf("failed\n")
+
+	// Assert
+	assert.Equal(t, "api => services/api\n", stdout.String())
+	assert.Equal(t, "Building api...\nretry\nfailed\n", stderr.String())
+}
+
+func TestLogger_Levels(t *testing.T) {
+	tests := []struct {
+		name     string
+		quiet    bool
+		verbose  int
+		expected string
+	}{
+		{name: "quiet", quiet: true, expected: "error\n"},
+		{name: "default", expected: "error\nwarn\ninfo\n"},
+		{name: "verbose", verbose: 1, expected: "error\nwarn\ninfo\ndebug\n"},
+		{name: "very verbose", verbose: 2, expected: "error\nwarn\ninfo\ndebug\ntrace\n"},
+		{name: "more verbose", verbose: 5, expected: "error\nwarn\ninfo\ndebug\ntrace\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, stdout, stderr := newTestLogger(Options{Level: VerbosityLevel(tt.quiet, tt.verbose), NoColor: true})
+
+			l.Errorf("error\n")
+			l.Warnf("warn\n")
+			l.Infof("info\n")
+			l.Debugf("debug\n")
+			l.Tracef("trace\n")
+			l.Printf("data\n")
+
+			assert.Equal(t, tt.expected, stderr.String())
+			assert.Equal(t, "data\n", stdout.String())
+		})
+	}
+}
+
+func TestLogger_JSONFormat(t *testing.T) {
+	// Arrange
+	l, _, stderr := newTestLogger(Options{Level: LevelInfo, Format: FormatJSON})
+
+	// Act
+	l.Warnf("no project has tag %q\n", color.New(color.FgBlue).Add(color.Bold).Sprint("api"))
+
+	// Assert
+	var e map[string]string
+	assert.NoError(t, json.Unmarshal(stderr.Bytes(), &e))
+	assert.Equal(t, "warn", e["level"])
+	assert.NotContains(t, e["msg"], "\x1b")
+	assert.Contains(t, e["msg"], "no project has tag")
+	assert.NotEmpty(t, e["time"])
+}
+
+func TestLoggerErrorWith_FieldsInJSON(t *testing.T) {
+	// Arrange
+	text, _, textErr := newTestLogger(Options{Level: LevelInfo, NoColor: true})
+	structured, _, structuredErr := newTestLogger(Options{Level: LevelInfo, Format: FormatJSON})
+	fields := map[string]string{"project": "api", "stderr": "no Go files"}
+
+	// Act
+	text.ErrorWith(fields, "error loading modules of api\n")
+	structured.ErrorWith(fields, "error loading modules of api\n")
+
+	// Assert
+	var e map[string]string
+	assert.NoError(t, json.Unmarshal(structuredErr.Bytes(), &e))
+	assert.Equal(t, "error", e["level"])
+	assert.Equal(t, "api", e["project"])
+	assert.Equal(t, "no Go files", e["stderr"])
+	assert.Equal(t, "error loading modules of api\n", textErr.String())
+}
+
+func TestLogger_NoColor(t *testing.T) {
+	// Arrange
+	noColor := color.NoColor
+	color.NoColor = false
+	defer func() { color.NoColor = noColor }()
+	colored, _, coloredErr := newTestLogger(Options{Level: LevelInfo})
+	plain, _, plainErr := newTestLogger(Options{Level: LevelInfo, NoColor: true})
+
+	// Act
+	colored.Errorf("failed\n")
+	plain.Errorf("failed\n")
+
+	// Assert
+	assert.Contains(t, coloredErr.String(), "\x1b[31m")
+	assert.Equal(t, "failed\n", plainErr.String())
+}
+
+func TestDiscard(t *testing.T) {
+	assert.False(t, Discard().Enabled(LevelError))
+}
+
+func TestParseFormat(t *testing.T) {
+	format, err := ParseFormat("json")
+	assert.NoError(t, err)
+	assert.Equal(t, FormatJSON, format)
+
+	_, err = ParseFormat("yaml")
+	assert.Error(t, err)
+}
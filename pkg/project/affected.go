@@ -1,11 +1,13 @@
 package project
 
 import (
+	"context"
 	"fmt"
 	"path"
+	"sync/atomic"
+	"time"
 
 	"github.com/fatih/color"
-	"github.com/kperreau/goac/pkg/printer"
 	"github.com/kperreau/goac/pkg/utils"
 	"golang.org/x/sync/errgroup"
 )
@@ -20,43 +22,155 @@ const (
 
 func (t Target) String() string { return string(t) }
 
-func (l *List) Affected() error {
+// Affected builds the affected projects and records a summary of the run.
+// Unless KeepGoing is set, projects not started yet are skipped after the first failure.
+// When ctx is canceled, running builds are killed and skipped as interrupted, projects not started yet
+// are skipped, the cache of the projects built before is kept.
+func (l *List) Affected(ctx context.Context) error {
+	if l.shardPlan != nil && l.Options.PrintShardPlan {
+		l.shardPlan.print(l.Options.logger(), l.Options.Shard)
+	}
 	l.printAffected()
 
+	start := time.Now()
+	results := make([]*Result, len(l.Projects))
+	failed := atomic.Bool{}
+
 	eg := errgroup.Group{}
 	eg.SetLimit(l.Options.MaxConcurrency)
-	for _, p := range l.Projects {
+	for i, p := range l.Projects {
 		eg.Go(func() error {
-			return processAffected(p)
+			// the reason is read before the build updates the cache
+			reason := p.affectedReason()
+			if reason == "" {
+				p.CMDOptions.logger().Debugf("%s: cache hit\n", p.Name)
+				p.emit(Event{Type: EventCacheHit})
+			} else {
+				p.CMDOptions.logger().Debugf("%s: cache miss, %s\n", p.Name, reason)
+				p.emit(Event{Type: EventCacheMiss, Reason: reason})
+			}
+			if ctx.Err() != nil || (failed.Load() && !l.Options.KeepGoing) {
+				results[i] = newResult(p, ResultSkipped, 0, nil)
+			} else {
+				results[i] = runAffected(ctx, p)
+			}
+			if results[i].Reason == "" {
+				results[i].Reason = reason
+			}
+
+			if results[i].Status == ResultFailed {
+				failed.Store(true)
+			}
+			return nil
 		})
 	}
+	_ = eg.Wait()
 
-	if err := eg.Wait(); err != nil {
-		return err
+	l.summary = &Summary{
+		Target:   l.Options.Target,
+		Results:  results,
+		Duration: time.Since(start),
+		Seconds:  time.Since(start).Seconds(),
+	}
+	if !l.Options.DryRun {
+		l.summary.print(l.Options.logger())
 	}
 
-	return nil
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("interrupted: %w", err)
+	}
+
+	return l.summary.Err()
+}
+
+// Summary returns the summary of the last affected run, nil before.
+func (l *List) Summary() *Summary {
+	return l.summary
+}
+
+// AffectedProjects returns the affected projects defining the target with the reason they are affected,
+// without building them.
+func (l *List) AffectedProjects() []*Result {
+	var results []*Result
+	for _, p := range l.Projects {
+		if p.Target[l.Options.Target] == nil || !p.isAffected() {
+			continue
+		}
+		r := newResult(p, ResultAffected, 0, nil)
+		r.Reason = p.affectedReason()
+		results = append(results, r)
+	}
+	return results
+}
+
+func runAffected(ctx context.Context, p *Project) *Result {
+	status := ResultCached
+	if p.isAffected() {
+		status = ResultBuilt
+		if p.CMDOptions.DryRun {
+			status = ResultAffected
+		}
+	}
+
+	start := time.Now()
+	if err := processAffected(ctx, p); err != nil {
+		// the build killed by the cancellation of ctx did not fail
+		if ctx.Err() != nil {
+			r := newResult(p, ResultSkipped, time.Since(start), nil)
+			r.Reason = "interrupted"
+			return r
+		}
+		logProjectError(p.CMDOptions.logger(), err, "%s: %s\n", p.Name, err)
+		return newResult(p, ResultFailed, time.Since(start), err)
+	}
+
+	return newResult(p, status, time.Since(start), nil)
 }
 
-func processAffected(p *Project) error {
+func processAffected(ctx context.Context, p *Project) error {
 	isAffected := p.isAffected()
 
 	if isAffected && p.CMDOptions.DryRun {
-		printer.Printf("%s %s %s\n", color.BlueString(p.Name), color.YellowString("=>"), p.CleanPath)
+		p.CMDOptions.logger().Printf("%s %s %s\n", color.BlueString(p.Name), color.YellowString("=>"), p.CleanPath)
 	}
 
-	if p.CMDOptions.DryRun || !isAffected {
+	if p.CMDOptions.DryRun {
 		return nil
 	}
 
-	if err := p.build(); err != nil {
-		return fmt.Errorf("error building: %s", err.Error())
+	if !isAffected {
+		// record a content cache hit in the project cache entry
+		if !p.isPathCached() {
+			return p.tracedWriteCache()
+		}
+		return nil
+	}
+
+	span := p.span("build")
+	start := time.Now()
+	err := p.build(ctx)
+	span.End()
+	if err != nil {
+		p.emit(Event{Type: EventTargetFailed, Duration: time.Since(start), Err: err})
+		return err
 	}
+	p.Metadata.Duration = time.Since(start)
+	p.emit(Event{Type: EventTargetFinished, Duration: p.Metadata.Duration})
+
+	if err := p.tracedWriteCache(); err != nil {
+		return err
+	}
+
+	return nil
+}
 
+func (p *Project) tracedWriteCache() error {
+	defer p.span("write cache").End()
 	if err := p.writeCache(); err != nil {
 		return err
 	}
 
+	p.emit(Event{Type: EventCacheWritten})
 	return nil
 }
 
@@ -74,17 +188,49 @@ func (p *Project) isAffected() bool {
 		return true
 	}
 
-	if p.Cache.Target[p.CMDOptions.Target] == nil || !p.Cache.Target[p.CMDOptions.Target].isMetadataMatch(p.Metadata) {
+	if !p.isPathCached() && !p.isContentCached() {
 		return true
 	}
 
-	if p.CMDOptions.BinaryCheck && !utils.FileExist(path.Join(p.CleanPath, p.Name)) {
+	if p.CMDOptions.BinaryCheck && !utils.FileExist(p.CMDOptions.path(path.Join(p.CleanPath, p.Name))) {
 		return true
 	}
 
 	return false
 }
 
+// affectedReason explains why the project is affected, it is empty if the project is not affected.
+func (p *Project) affectedReason() string {
+	if !p.isAffected() {
+		return ""
+	}
+	if p.plannedReason != "" {
+		return p.plannedReason
+	}
+	if p.CMDOptions.Force {
+		return "forced"
+	}
+
+	cached := p.Cache.Target[p.CMDOptions.Target]
+	switch {
+	case cached == nil:
+		return "not built yet"
+	case cached.DependenciesHash != p.Metadata.DependenciesHash && cached.DirHash != p.Metadata.DirHash:
+		return "dependencies and files changed"
+	case cached.DependenciesHash != p.Metadata.DependenciesHash:
+		return "dependencies changed"
+	case cached.DirHash != p.Metadata.DirHash:
+		return "files changed"
+	case !cached.isMetadataMatch(p.Metadata):
+		return "hashing changed"
+	}
+	return "binary missing"
+}
+
+func (p *Project) isPathCached() bool {
+	return p.Cache.Target[p.CMDOptions.Target] != nil && p.Cache.Target[p.CMDOptions.Target].isMetadataMatch(p.Metadata)
+}
+
 func StringToTarget(s string) Target {
 	switch s {
 	case TargetBuild.String():
@@ -101,5 +247,9 @@ func (l *List) printAffected() {
 	if affectedCounter == 0 {
 		affected = color.HiBlackString("%d", affectedCounter)
 	}
-	printer.Printf("Affected: %s/%s\n", affected, color.HiBlueString("%d", len(l.Projects)))
+	if l.shardPlan != nil {
+		l.Options.logger().Infof("Shard %s: %s affected projects\n", l.Options.Shard, affected)
+		return
+	}
+	l.Options.logger().Infof("Affected: %s/%s\n", affected, color.HiBlueString("%d", len(l.Projects)))
 }
@@ -2,13 +2,17 @@ package project
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/fatih/color"
+	"github.com/kperreau/goac/pkg/logger"
+	"github.com/kperreau/goac/pkg/trace"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -315,26 +319,31 @@ func TestCountAffected_TwoProjects(t *testing.T) {
 
 func TestAffected_Prints0AffectedProjects(t *testing.T) {
 	// Initialize the List object
+	var stdout, stderr bytes.Buffer
 	l := &List{
 		Projects: []*Project{},
-		Options:  &Options{},
+		Options:  &Options{Logger: newTestLogger(logger.LevelInfo, &stdout, &stderr)},
 	}
 
-	output, err := redirectAffectedStdout(l.Affected)
+	err := l.Affected(context.Background())
 	assert.NoError(t, err)
 
-	// Assert that the correct output is printed
-	expectedOutput := fmt.Sprintf("Affected: %s/%s\n", color.HiBlueString("%d", 0), color.HiBlueString("%d", len(l.Projects)))
-	assert.Equal(t, expectedOutput, output.String())
+	// Assert that nothing is printed to stdout, and the count and the summary to stderr
+	assert.Empty(t, stdout.String())
+	expectedOutput := fmt.Sprintf("Affected: %s/%s\nSummary: 0 built, 0 cached, 0 skipped, 0 failed in ", color.HiBlueString("%d", 0), color.HiBlueString("%d", len(l.Projects)))
+	assert.True(t, strings.HasPrefix(stderr.String(), expectedOutput), stderr.String())
+	assert.Contains(t, stderr.String(), "PROJECT  PATH  STATUS  DURATION  ERROR\n")
 }
 
 func TestAffected_Prints1AffectedProjects(t *testing.T) {
 	// Initialize the List object
-	// Initialize the List object
+	var stdout, stderr bytes.Buffer
+	log := newTestLogger(logger.LevelInfo, &stdout, &stderr)
 	l := &List{
 		Projects: []*Project{
 			{
 				CMDOptions: &Options{
+					Logger:         log,
 					Force:          true,
 					DryRun:         true,
 					Target:         TargetBuild,
@@ -359,6 +368,7 @@ func TestAffected_Prints1AffectedProjects(t *testing.T) {
 			},
 			{
 				CMDOptions: &Options{
+					Logger:         log,
 					DryRun:         true,
 					Target:         TargetBuild,
 					MaxConcurrency: 2,
@@ -382,18 +392,20 @@ func TestAffected_Prints1AffectedProjects(t *testing.T) {
 			},
 		},
 		Options: &Options{
+			Logger:         log,
 			MaxConcurrency: 2,
 			Target:         TargetBuild,
 			DryRun:         true,
 		},
 	}
 
-	output, err := redirectAffectedStdout(l.Affected)
+	err := l.Affected(context.Background())
 	assert.NoError(t, err)
 
-	// Assert that the correct output is printed
-	expectedOutput := fmt.Sprintf("Affected: %s/%s\ngoac => .\n", color.HiBlueString("%d", 1), color.HiBlueString("%d", len(l.Projects)))
-	assert.Equal(t, expectedOutput, output.String())
+	// Assert that the affected project is printed to stdout, and the count to stderr
+	assert.Equal(t, "goac => .\n", stdout.String())
+	expectedOutput := fmt.Sprintf("Affected: %s/%s\n", color.HiBlueString("%d", 1), color.HiBlueString("%d", len(l.Projects)))
+	assert.Equal(t, expectedOutput, stderr.String())
 }
 
 func TestAffected_MaxConcurrencyOne(t *testing.T) {
@@ -432,7 +444,7 @@ func TestAffected_MaxConcurrencyOne(t *testing.T) {
 	}
 
 	// Call the Affected method
-	_, err := redirectAffectedStdout(l.Affected)
+	_, err := redirectAffectedStdout(func() error { return l.Affected(context.Background()) })
 	assert.NoError(t, err)
 }
 
@@ -468,7 +480,7 @@ func TestProcessAffected_BuildAffectedProject(t *testing.T) {
 	os.Stdout = w
 
 	// Call the processAffected function
-	err := processAffected(p)
+	err := processAffected(context.Background(), p)
 	assert.NoError(t, err)
 
 	// Restore stdout
@@ -488,6 +500,7 @@ func TestProcessAffected_BuildAffectedProject(t *testing.T) {
 
 func TestPrintAffected_1AffectedAnd1Project(t *testing.T) {
 	// Initialize the List object
+	var stdout, stderr bytes.Buffer
 	l := &List{
 		Projects: []*Project{
 			{Name: "Project1", CleanPath: "/path/to/project1", CMDOptions: &Options{DryRun: true, Force: true}},
@@ -495,24 +508,28 @@ func TestPrintAffected_1AffectedAnd1Project(t *testing.T) {
 		Options: &Options{
 			DryRun: true,
 			Force:  true,
+			Logger: newTestLogger(logger.LevelInfo, &stdout, &stderr),
 		},
 	}
 
 	// Call the List method and return output
-	buf, _ := redirectAffectedStdout(func() error { l.printAffected(); return nil })
+	l.printAffected()
 
 	// Assert the printed output
+	assert.Empty(t, stdout.String())
 	expectedOutput := "Affected: 1/1\n"
-	assert.Equal(t, expectedOutput, buf.String())
+	assert.Equal(t, expectedOutput, stderr.String())
 }
 
 func TestPrintAffected_0AffectedAnd1Project(t *testing.T) {
 	// Initialize the List object
+	var stdout, stderr bytes.Buffer
 	opts := &Options{
 		DryRun:      true,
 		BinaryCheck: false,
 		Force:       false,
 		Target:      TargetBuild,
+		Logger:      newTestLogger(logger.LevelInfo, &stdout, &stderr),
 	}
 	l := &List{
 		Projects: []*Project{
@@ -535,11 +552,12 @@ func TestPrintAffected_0AffectedAnd1Project(t *testing.T) {
 	}
 
 	// Call the List method and return output
-	buf, _ := redirectAffectedStdout(func() error { l.printAffected(); return nil })
+	l.printAffected()
 
 	// Assert the printed output
+	assert.Empty(t, stdout.String())
 	expectedOutput := "Affected: 0/1\n"
-	assert.Equal(t, expectedOutput, buf.String())
+	assert.Equal(t, expectedOutput, stderr.String())
 }
 
 func redirectAffectedStdout(f func() error) (*bytes.Buffer, error) {
@@ -565,3 +583,179 @@ func redirectAffectedStdout(f func() error) (*bytes.Buffer, error) {
 
 	return &buf, err
 }
+
+func TestIsAffected_ContentCachedFromAnotherPath_ReturnFalse(t *testing.T) {
+	// Arrange
+	tmpDir := t.TempDir()
+	OldDefaultCachePath := DefaultCachePath
+	DefaultCachePath = tmpDir
+	defer func() { DefaultCachePath = OldDefaultCachePath }()
+
+	moved := &Project{
+		Name:       "goac",
+		CleanPath:  "old/goac",
+		HashPath:   "old",
+		Cache:      &Cache{Target: make(map[Target]*Metadata)},
+		CMDOptions: &Options{Target: TargetBuild},
+		Metadata:   &Metadata{DependenciesHash: "deps", DirHash: "old-dir", ContentHash: "content"},
+	}
+	assert.NoError(t, moved.writeCache())
+
+	p := &Project{
+		Name:       "goac",
+		CleanPath:  "new/goac",
+		HashPath:   "new",
+		Cache:      &Cache{Target: make(map[Target]*Metadata)},
+		CMDOptions: &Options{Target: TargetBuild},
+		Metadata:   &Metadata{DependenciesHash: "deps", DirHash: "new-dir", ContentHash: "content"},
+	}
+
+	// Act
+	result := p.isAffected()
+
+	// Assert
+	assert.False(t, result)
+}
+
+func newFailingProject(name string) *Project {
+	return &Project{
+		Name:       name,
+		CleanPath:  name,
+		CMDOptions: &Options{Force: true, Target: TargetBuild},
+		Target:     map[Target]*TargetConfig{TargetBuild: {Exec: &Exec{CMD: "false"}}},
+		Metadata:   &Metadata{},
+		Cache:      &Cache{Target: map[Target]*Metadata{}},
+	}
+}
+
+func TestAffected_FailFastSkipsRemainingProjects(t *testing.T) {
+	// Arrange
+	var stdout, stderr bytes.Buffer
+	l := &List{
+		Projects: []*Project{newFailingProject("a"), newFailingProject("b")},
+		Options:  &Options{Target: TargetBuild, MaxConcurrency: 1, Logger: newTestLogger(logger.LevelInfo, &stdout, &stderr)},
+	}
+
+	// Act
+	err := l.Affected(context.Background())
+
+	// Assert
+	assert.EqualError(t, err, "1/2 projects failed")
+	assert.Equal(t, ResultFailed, l.Summary().Results[0].Status)
+	assert.Contains(t, l.Summary().Results[0].Error, "exit status 1")
+	assert.Equal(t, ResultSkipped, l.Summary().Results[1].Status)
+	assert.Empty(t, stdout.String())
+	assert.Contains(t, stderr.String(), "Summary: 0 built, 0 cached, 1 skipped, 1 failed in ")
+	assert.Equal(t, "forced", l.Summary().Results[0].Reason)
+}
+
+func TestAffected_KeepGoingRunsEveryProject(t *testing.T) {
+	// Arrange
+	l := &List{
+		Projects: []*Project{newFailingProject("a"), newFailingProject("b")},
+		Options:  &Options{Target: TargetBuild, MaxConcurrency: 1, KeepGoing: true},
+	}
+
+	// Act
+	_, err := redirectAffectedStdout(func() error { return l.Affected(context.Background()) })
+
+	// Assert
+	assert.EqualError(t, err, "2/2 projects failed")
+	assert.Equal(t, 2, l.Summary().Count(ResultFailed))
+}
+
+func TestAffected_CanceledContextSkipsProjects(t *testing.T) {
+	// Arrange
+	l := &List{
+		Projects: []*Project{newFailingProject("a")},
+		Options:  &Options{Target: TargetBuild, MaxConcurrency: 1},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Act
+	_, err := redirectAffectedStdout(func() error { return l.Affected(ctx) })
+
+	// Assert
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, ResultSkipped, l.Summary().Results[0].Status)
+}
+
+func TestAffectedReason(t *testing.T) {
+	// Arrange
+	cached := &Metadata{DependenciesHash: "deps", DirHash: "dir"}
+	newProject := func(metadata *Metadata, cache map[Target]*Metadata) *Project {
+		return &Project{
+			CMDOptions: &Options{Target: TargetBuild},
+			Metadata:   metadata,
+			Cache:      &Cache{Target: cache},
+		}
+	}
+
+	// Act & Assert
+	assert.Equal(t, "", newProject(&Metadata{DependenciesHash: "deps", DirHash: "dir"}, map[Target]*Metadata{TargetBuild: cached}).affectedReason())
+	assert.Equal(t, "not built yet", newProject(&Metadata{}, map[Target]*Metadata{}).affectedReason())
+	assert.Equal(t, "files changed", newProject(&Metadata{DependenciesHash: "deps", DirHash: "new"}, map[Target]*Metadata{TargetBuild: cached}).affectedReason())
+	assert.Equal(t, "dependencies changed", newProject(&Metadata{DependenciesHash: "new", DirHash: "dir"}, map[Target]*Metadata{TargetBuild: cached}).affectedReason())
+
+	forced := newProject(&Metadata{DependenciesHash: "deps", DirHash: "dir"}, map[Target]*Metadata{TargetBuild: cached})
+	forced.CMDOptions.Force = true
+	assert.Equal(t, "forced", forced.affectedReason())
+}
+
+func TestProcessAffected_RecordsSpans(t *testing.T) {
+	// Arrange
+	OldDefaultCachePath := DefaultCachePath
+	DefaultCachePath = t.TempDir()
+	defer func() { DefaultCachePath = OldDefaultCachePath }()
+
+	tracer := trace.New()
+	p := &Project{
+		Name:       "goac",
+		CleanPath:  ".",
+		HashPath:   "hash",
+		CMDOptions: &Options{Force: true, Target: TargetBuild, Tracer: tracer},
+		Target:     map[Target]*TargetConfig{TargetBuild: {Exec: &Exec{CMD: "true"}}},
+		Metadata:   &Metadata{},
+		Cache:      &Cache{Target: map[Target]*Metadata{}},
+	}
+
+	// Act
+	_, err := redirectAffectedStdout(func() error { return processAffected(context.Background(), p) })
+
+	// Assert
+	assert.NoError(t, err)
+	spans := tracer.Spans()
+	assert.Len(t, spans, 2)
+	assert.Equal(t, "build", spans[0].Name)
+	assert.Equal(t, ".", spans[0].Lane)
+	assert.Equal(t, "goac", spans[0].Attributes["project"])
+	assert.Equal(t, "write cache", spans[1].Name)
+}
+
+func TestAffectedProjects(t *testing.T) {
+	// Arrange
+	opt := &Options{Target: TargetBuild}
+	cached := &Metadata{DependenciesHash: "deps", DirHash: "dir"}
+	newProject := func(name string, metadata *Metadata, config *TargetConfig) *Project {
+		return &Project{
+			Name:       name,
+			CleanPath:  "services/" + name,
+			CMDOptions: opt,
+			Target:     map[Target]*TargetConfig{TargetBuild: config},
+			Metadata:   metadata,
+			Cache:      &Cache{Target: map[Target]*Metadata{TargetBuild: cached}},
+		}
+	}
+	l := &List{Options: opt, Projects: []*Project{
+		newProject("cached", &Metadata{DependenciesHash: "deps", DirHash: "dir"}, &TargetConfig{}),
+		newProject("changed", &Metadata{DependenciesHash: "deps", DirHash: "new"}, &TargetConfig{}),
+		newProject("no-target", &Metadata{DependenciesHash: "deps", DirHash: "new"}, nil),
+	}}
+
+	// Act
+	results := l.AffectedProjects()
+
+	// Assert
+	assert.Equal(t, []*Result{{Name: "changed", Path: "services/changed", Status: ResultAffected, Reason: "files changed"}}, results)
+}
@@ -1,38 +1,136 @@
 package project
 
 import (
-	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/fatih/color"
-	"github.com/kperreau/goac/pkg/printer"
+	"github.com/kperreau/goac/pkg/utils"
 )
 
-func (p *Project) build() error {
-	printer.Printf("Building %s...\n", color.HiBlueString(p.Name))
+// retryBackoff is the delay before the first retry of a failed build, doubled on each retry up to maxRetryBackoff.
+var retryBackoff = time.Second
+
+const maxRetryBackoff = 30 * time.Second
+
+// waitDelay is the time given to a killed build to release its output before giving up on it.
+const waitDelay = 5 * time.Second
+
+func (p *Project) build(ctx context.Context) error {
+	p.CMDOptions.logger().Infof("Building %s...\n", color.HiBlueString(p.Name))
 
 	// replace variables env and params to proper values
 	replaceAllVariables(p)
+	p.emit(Event{Type: EventTargetStarted})
+
+	config := p.Target[p.CMDOptions.Target]
+	timeout, err := config.timeout()
+	if err != nil {
+		return fmt.Errorf("error building %s: %w", p.Name, err)
+	}
+
+	backoff := retryBackoff
+	for attempt := 1; ; attempt++ {
+		err := p.exec(ctx, timeout)
+		if err == nil || attempt > config.Retries || ctx.Err() != nil {
+			return err
+		}
+
+		p.CMDOptions.logger().Warnf("Build of %s failed, retry %d/%d in %s\n", p.Name, attempt, config.Retries, backoff)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxRetryBackoff)
+	}
+}
+
+// exec runs the target command once, its whole process group is killed on timeout or cancellation.
+func (p *Project) exec(ctx context.Context, timeout time.Duration) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
 
-	var stderr bytes.Buffer
-	cmd := exec.Command(p.Target[p.CMDOptions.Target].Exec.CMD, p.Target[p.CMDOptions.Target].Exec.Params...)
+	mu := &sync.Mutex{}
+	stdout := &outputWriter{p: p, stream: StreamStdout, mu: mu}
+	stderr := &outputWriter{p: p, stream: StreamStderr, mu: mu}
+	cmd := exec.CommandContext(ctx, p.Target[p.CMDOptions.Target].Exec.CMD, p.Target[p.CMDOptions.Target].Exec.Params...)
+	cmd.Dir = p.CMDOptions.rootPath()
+	p.CMDOptions.logger().Debugf("%s: running %s\n", p.Name, cmd)
 	setEnv(p, cmd)
-	cmd.Stderr = &stderr
-	output, err := cmd.Output()
+	setProcessGroup(cmd)
+	cmd.WaitDelay = waitDelay
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	err := cmd.Run()
+	stdout.flush()
+	stderr.flush()
 	if err != nil {
-		return fmt.Errorf("%s: %s", err, stderr.String())
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("timed out after %s", timeout)
+		}
+		return &BuildError{
+			Project: p.Name,
+			Path:    p.CleanPath,
+			Command: cmd.String(),
+			Err:     err,
+			Stdout:  stdout.output.String(),
+			Stderr:  stderr.output.String(),
+		}
 	}
 
 	if p.CMDOptions.PrintStdout {
-		fmt.Print(string(output))
+		p.CMDOptions.logger().Printf("%s", stdout.output.String())
 	}
 
 	return nil
 }
 
+// BuildError is the failure of the target command of a project, with its output.
+type BuildError struct {
+	Project string
+	Path    string
+	Command string
+	Err     error
+	Stdout  string
+	Stderr  string
+}
+
+func (e *BuildError) Error() string {
+	msg := fmt.Sprintf("error building %s: %s", e.Project, e.Err)
+	if e.Command != "" {
+		msg = fmt.Sprintf("error building %s: %s: %s", e.Project, e.Command, e.Err)
+	}
+	if stderr := strings.TrimSpace(e.Stderr); stderr != "" {
+		msg += ": " + stderr
+	}
+	return msg
+}
+
+func (e *BuildError) Unwrap() error {
+	return e.Err
+}
+
+func (c *TargetConfig) timeout() (time.Duration, error) {
+	if c.Timeout == "" {
+		return 0, nil
+	}
+
+	d, err := utils.ParseDuration(c.Timeout)
+	if err != nil {
+		return 0, fmt.Errorf("invalid target timeout: %w", err)
+	}
+	return d, nil
+}
+
 func setEnv(p *Project, cmd *exec.Cmd) {
 	cmd.Env = os.Environ()
 	for _, env := range p.Target[p.CMDOptions.Target].Envs {
@@ -41,21 +139,24 @@ func setEnv(p *Project, cmd *exec.Cmd) {
 }
 
 func replaceAllVariables(p *Project) {
-	// init variables
+	for i := range p.Target[p.CMDOptions.Target].Envs {
+		p.Target[p.CMDOptions.Target].Envs[i].Value = p.replaceVariables(p.Target[p.CMDOptions.Target].Envs[i].Value)
+	}
+
+	for i := range p.Target[p.CMDOptions.Target].Exec.Params {
+		p.Target[p.CMDOptions.Target].Exec.Params[i] = p.replaceVariables(p.Target[p.CMDOptions.Target].Exec.Params[i])
+	}
+}
+
+// replaceVariables replaces the variables of the project in s.
+func (p *Project) replaceVariables(s string) string {
 	variables := map[string]string{
 		"{{project-name}}": p.Name,
 		"{{project-path}}": p.Path,
 	}
 
-	for i := range p.Target[p.CMDOptions.Target].Envs {
-		for search, replace := range variables {
-			p.Target[p.CMDOptions.Target].Envs[i].Value = strings.ReplaceAll(p.Target[p.CMDOptions.Target].Envs[i].Value, search, replace)
-		}
-	}
-
-	for i := range p.Target[p.CMDOptions.Target].Exec.Params {
-		for search, replace := range variables {
-			p.Target[p.CMDOptions.Target].Exec.Params[i] = strings.ReplaceAll(p.Target[p.CMDOptions.Target].Exec.Params[i], search, replace)
-		}
+	for search, replace := range variables {
+		s = strings.ReplaceAll(s, search, replace)
 	}
+	return s
 }
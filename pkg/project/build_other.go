@@ -0,0 +1,8 @@
+//go:build !unix
+
+package project
+
+import "os/exec"
+
+// setProcessGroup is a no-op, only the command itself is killed on cancellation.
+func setProcessGroup(*exec.Cmd) {}
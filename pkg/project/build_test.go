@@ -2,16 +2,21 @@ package project
 
 import (
 	"bytes"
+	"context"
 	"io"
 	"log"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/kperreau/goac/pkg/logger"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestBuild_WithDefaultParameters(t *testing.T) {
+	var stdout, stderr bytes.Buffer
 	tmp, _ := os.MkdirTemp("", "test-build")
 	p := &Project{
 		Name: "test-project",
@@ -25,17 +30,19 @@ func TestBuild_WithDefaultParameters(t *testing.T) {
 			},
 		},
 		CMDOptions: &Options{
+			Logger: newTestLogger(logger.LevelInfo, &stdout, &stderr),
 			Target: TargetBuild,
 		},
 	}
 
-	output, err := redirectBuildStdout(p.build)
+	err := p.build(context.Background())
 
 	assert.NoError(t, err)
-	assert.Contains(t, output.String(), "Building test-project...")
+	assert.Contains(t, stderr.String(), "Building test-project...")
 }
 
 func TestBuild_PrintStdout(t *testing.T) {
+	var stdout, stderr bytes.Buffer
 	p := &Project{
 		Name: "test-project",
 		Target: map[Target]*TargetConfig{
@@ -47,19 +54,21 @@ func TestBuild_PrintStdout(t *testing.T) {
 			},
 		},
 		CMDOptions: &Options{
+			Logger:      newTestLogger(logger.LevelInfo, &stdout, &stderr),
 			Target:      TargetBuild,
 			PrintStdout: true,
 		},
 	}
 
-	output, err := redirectBuildStdout(p.build)
+	err := p.build(context.Background())
 
 	assert.NoError(t, err)
-	assert.Contains(t, output.String(), "Building test-project...")
-	assert.Contains(t, output.String(), "should print this")
+	assert.Contains(t, stderr.String(), "Building test-project...")
+	assert.Equal(t, "should print this\n", stdout.String())
 }
 
 func TestBuild_WithoutPrintStdout(t *testing.T) {
+	var stdout, stderr bytes.Buffer
 	p := &Project{
 		Name: "test-project",
 		Target: map[Target]*TargetConfig{
@@ -71,18 +80,20 @@ func TestBuild_WithoutPrintStdout(t *testing.T) {
 			},
 		},
 		CMDOptions: &Options{
+			Logger: newTestLogger(logger.LevelInfo, &stdout, &stderr),
 			Target: TargetBuild,
 		},
 	}
 
-	output, err := redirectBuildStdout(p.build)
+	err := p.build(context.Background())
 
 	assert.NoError(t, err)
-	assert.Contains(t, output.String(), "Building test-project...")
-	assert.NotContains(t, output.String(), "should not print this")
+	assert.Contains(t, stderr.String(), "Building test-project...")
+	assert.Empty(t, stdout.String())
 }
 
 func TestBuild_CommandFails(t *testing.T) {
+	var stdout, stderr bytes.Buffer
 	p := &Project{
 		Name: "test-project",
 		Target: map[Target]*TargetConfig{
@@ -94,14 +105,15 @@ func TestBuild_CommandFails(t *testing.T) {
 			},
 		},
 		CMDOptions: &Options{
+			Logger: newTestLogger(logger.LevelInfo, &stdout, &stderr),
 			Target: TargetBuild,
 		},
 	}
 
-	output, err := redirectBuildStdout(p.build)
+	err := p.build(context.Background())
 
 	assert.Error(t, err)
-	assert.Contains(t, output.String(), "Building test-project...")
+	assert.Contains(t, stderr.String(), "Building test-project...")
 }
 
 func redirectBuildStdout(f func() error) (*bytes.Buffer, error) {
@@ -190,3 +202,69 @@ func TestReplaceAllVariables_ReplaceVariables(t *testing.T) {
 	assert.Equal(t, expectedParamName, p.Target[TargetBuild].Exec.Params[0])
 	assert.Equal(t, expectedParamPath, p.Target[TargetBuild].Exec.Params[1])
 }
+
+func newShellProject(config *TargetConfig) *Project {
+	return &Project{
+		Name:       "test-project",
+		Target:     map[Target]*TargetConfig{TargetBuild: config},
+		CMDOptions: &Options{Target: TargetBuild},
+	}
+}
+
+func TestBuild_Timeout(t *testing.T) {
+	p := newShellProject(&TargetConfig{
+		Timeout: "100ms",
+		Exec:    &Exec{CMD: "sleep", Params: []string{"10"}},
+	})
+
+	start := time.Now()
+	_, err := redirectBuildStdout(func() error { return p.build(context.Background()) })
+
+	assert.ErrorContains(t, err, "timed out after 100ms")
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestBuild_InvalidTimeout(t *testing.T) {
+	p := newShellProject(&TargetConfig{Timeout: "soon", Exec: &Exec{CMD: "true"}})
+
+	_, err := redirectBuildStdout(func() error { return p.build(context.Background()) })
+
+	assert.ErrorContains(t, err, "invalid target timeout")
+}
+
+func TestBuild_RetriesUntilSuccess(t *testing.T) {
+	oldRetryBackoff := retryBackoff
+	retryBackoff = time.Millisecond
+	defer func() { retryBackoff = oldRetryBackoff }()
+
+	// fails on the first two attempts
+	counter := filepath.Join(t.TempDir(), "counter")
+	var stdout, stderr bytes.Buffer
+	p := newShellProject(&TargetConfig{
+		Retries: 2,
+		Exec:    &Exec{CMD: "sh", Params: []string{"-c", "echo >> " + counter + " && test $(wc -l < " + counter + ") -ge 3"}},
+	})
+	p.CMDOptions.Logger = newTestLogger(logger.LevelInfo, &stdout, &stderr)
+
+	err := p.build(context.Background())
+
+	assert.NoError(t, err)
+	assert.Empty(t, stdout.String())
+	assert.Contains(t, stderr.String(), "retry 2/2")
+}
+
+func TestBuild_RetriesExhausted(t *testing.T) {
+	oldRetryBackoff := retryBackoff
+	retryBackoff = time.Millisecond
+	defer func() { retryBackoff = oldRetryBackoff }()
+
+	var stdout, stderr bytes.Buffer
+	p := newShellProject(&TargetConfig{Retries: 1, Exec: &Exec{CMD: "false"}})
+	p.CMDOptions.Logger = newTestLogger(logger.LevelInfo, &stdout, &stderr)
+
+	err := p.build(context.Background())
+
+	assert.Error(t, err)
+	assert.Empty(t, stdout.String())
+	assert.Contains(t, stderr.String(), "retry 1/1")
+}
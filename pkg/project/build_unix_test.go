@@ -0,0 +1,66 @@
+//go:build unix
+
+package project
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/kperreau/goac/pkg/logger"
+	"github.com/kperreau/goac/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuild_CancelKillsProcessGroup(t *testing.T) {
+	// the child of the shell writes its pid, then keeps running
+	pidFile := filepath.Join(t.TempDir(), "pid")
+	p := newShellProject(&TargetConfig{
+		Exec: &Exec{CMD: "sh", Params: []string{"-c", "sleep 30 & echo $! > " + pidFile + "; wait"}},
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		assert.Eventually(t, func() bool { return utils.FileExist(pidFile) }, 5*time.Second, 10*time.Millisecond)
+		cancel()
+	}()
+
+	_, err := redirectBuildStdout(func() error { return p.build(ctx) })
+
+	assert.Error(t, err)
+	data, readErr := os.ReadFile(pidFile)
+	assert.NoError(t, readErr)
+	pid, convErr := strconv.Atoi(strings.TrimSpace(string(data)))
+	assert.NoError(t, convErr)
+	assert.Eventually(t, func() bool { return syscall.Kill(pid, 0) != nil }, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestAffected_CanceledBuildIsSkippedAsInterrupted(t *testing.T) {
+	// Arrange
+	started := filepath.Join(t.TempDir(), "started")
+	p := newFailingProject("a")
+	p.Target[TargetBuild].Exec = &Exec{CMD: "sh", Params: []string{"-c", "touch " + started + "; sleep 30"}}
+	p.CMDOptions.Logger = logger.Discard()
+	l := &List{
+		Projects: []*Project{p},
+		Options:  &Options{Target: TargetBuild, MaxConcurrency: 1, Logger: logger.Discard()},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		assert.Eventually(t, func() bool { return utils.FileExist(started) }, 5*time.Second, 10*time.Millisecond)
+		cancel()
+	}()
+
+	// Act
+	err := l.Affected(ctx)
+
+	// Assert
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, ResultSkipped, l.Summary().Results[0].Status)
+	assert.Equal(t, "interrupted", l.Summary().Results[0].Reason)
+	assert.Empty(t, l.Summary().Results[0].Error)
+}
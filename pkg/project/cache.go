@@ -4,38 +4,71 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/kperreau/goac/pkg/flock"
+	"github.com/kperreau/goac/pkg/hasher"
+	"github.com/kperreau/goac/pkg/utils"
 	"gopkg.in/yaml.v3"
 )
 
 type Cache struct {
-	Target map[Target]*Metadata
-	Path   string
+	Version int    `yaml:",omitempty"`
+	Name    string `yaml:",omitempty"`
+	Target  map[Target]*Metadata
+	Path    string
+	History map[Target][]string `yaml:",omitempty"`
 }
 
+// CacheFormatVersion is the version of the cache files schema.
+// Version 1 files have no version, their entries are SHA-1 hashes of the first hash scheme.
+const CacheFormatVersion = 2
+
 var DefaultCachePath = ".goac/cache/"
 
-func (p *Project) LoadCache() error {
-	cacheFilePath := fmt.Sprintf("%s%s.yaml", DefaultCachePath, p.HashPath)
+// DefaultCacheHistory is the number of content entries kept per project and target,
+// so that switching back to a previous state is still a cache hit.
+var DefaultCacheHistory = 10
+
+const contentCacheDir = "content"
 
-	// init a default basic cache
-	cacheData := Cache{Path: p.CleanPath, Target: map[Target]*Metadata{}}
+// lockCacheDir holds the lock files of the cache entries. They are kept apart from the entries so that
+// removing an entry never removes a lock held or waited on by another goac process.
+const lockCacheDir = "locks"
+
+func (p *Project) LoadCache() error {
+	cacheFilePath := filepath.Join(p.CMDOptions.cachePath(), fmt.Sprintf("%s.yaml", p.HashPath))
 
 	if _, err := os.Stat(cacheFilePath); os.IsNotExist(err) {
-		p.Cache = &cacheData
+		p.Cache = p.defaultCache()
 		return nil
 	}
 
-	if err := readCacheFromFile(cacheFilePath, &cacheData); err != nil {
-		return fmt.Errorf("error loading cache: %w", err)
+	// the version is read from the file, files without version are migrated from the first format
+	cacheData := Cache{Name: p.Name, Path: p.CleanPath, Target: map[Target]*Metadata{}}
+	err := readCacheFromFile(cacheFilePath, &cacheData)
+	if err == nil {
+		err = migrateCache(&cacheData)
+	}
+	if err != nil {
+		// an unreadable or corrupted entry is a cache miss, it is rewritten by the next build
+		p.CMDOptions.logger().Warnf("ignoring cache of %s: %s\n", p.Name, err)
+		p.Cache = p.defaultCache()
+		return nil
 	}
 
+	cacheData.invalidate(p.CMDOptions.hashAlgorithm(), p.CMDOptions.semanticHash())
 	p.Cache = &cacheData
 
 	return nil
 }
 
+// defaultCache inits an empty cache of the current format.
+func (p *Project) defaultCache() *Cache {
+	return &Cache{Version: CacheFormatVersion, Name: p.Name, Path: p.CleanPath, Target: map[Target]*Metadata{}}
+}
+
 func readCacheFromFile(path string, cache *Cache) error {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -49,31 +82,233 @@ func readCacheFromFile(path string, cache *Cache) error {
 	return nil
 }
 
+// migrateCache upgrades cache data read from an older format to CacheFormatVersion.
+func migrateCache(cache *Cache) error {
+	if cache.Version > CacheFormatVersion {
+		return fmt.Errorf("unsupported cache format version %d", cache.Version)
+	}
+
+	if cache.Target == nil {
+		cache.Target = map[Target]*Metadata{}
+	}
+
+	if cache.Version < 2 {
+		for _, metadata := range cache.Target {
+			if metadata == nil {
+				continue
+			}
+			if metadata.Algorithm == "" {
+				metadata.Algorithm = hasher.SHA1
+			}
+			if metadata.Scheme == 0 {
+				metadata.Scheme = 1
+			}
+		}
+	}
+
+	cache.Version = CacheFormatVersion
+
+	return nil
+}
+
+// invalidate drops the entries computed with another algorithm, hash scheme or semantic mode,
+// they can't match anymore.
+func (c *Cache) invalidate(algorithm hasher.Algorithm, semantic bool) {
+	for target, metadata := range c.Target {
+		if metadata == nil || metadata.Algorithm != algorithm || metadata.Scheme != HashSchemeVersion || metadata.Semantic != semantic {
+			delete(c.Target, target)
+			delete(c.History, target)
+		}
+	}
+}
+
 func (cm *Metadata) isMetadataMatch(m *Metadata) bool {
 	return cm.DependenciesHash == m.DependenciesHash &&
-		cm.DirHash == m.DirHash
+		cm.DirHash == m.DirHash &&
+		cm.Algorithm == m.Algorithm &&
+		cm.Scheme == m.Scheme &&
+		cm.Semantic == m.Semantic
 }
 
 func (p *Project) writeCache() error {
-	cacheFilePath := filepath.Join(DefaultCachePath, fmt.Sprintf("%s.yaml", p.HashPath))
+	cacheFilePath := filepath.Join(p.CMDOptions.cachePath(), fmt.Sprintf("%s.yaml", p.HashPath))
 
-	if err := os.MkdirAll(DefaultCachePath, 0o755); err != nil {
+	if err := os.MkdirAll(p.CMDOptions.cachePath(), 0o755); err != nil {
 		return fmt.Errorf("error creating cache directory: %v", err)
 	}
 
-	p.Cache.Target[p.CMDOptions.Target] = &Metadata{
+	lock, err := lockCacheFile(cacheFilePath)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = lock.Release() }()
+
+	// keep the other targets written by concurrent goac processes since the cache was loaded
+	p.mergeCacheFromFile(cacheFilePath)
+
+	// a cache hit keeps the duration of the last build
+	duration := p.Metadata.Duration
+	if cached := p.Cache.Target[p.CMDOptions.Target]; duration == 0 && cached != nil {
+		duration = cached.Duration
+	}
+
+	metadata := &Metadata{
 		DependenciesHash: p.Metadata.DependenciesHash,
 		DirHash:          p.Metadata.DirHash,
+		ContentHash:      p.Metadata.ContentHash,
+		Algorithm:        p.Metadata.Algorithm,
+		Scheme:           p.Metadata.Scheme,
+		Semantic:         p.Metadata.Semantic,
 		Date:             time.Now().Format(time.RFC3339),
+		Duration:         duration,
+	}
+
+	p.Cache.Version = CacheFormatVersion
+	p.Cache.Name = p.Name
+	p.Cache.Target[p.CMDOptions.Target] = metadata
+
+	if metadata.ContentHash != "" {
+		if err := p.writeContentCache(metadata); err != nil {
+			return err
+		}
+	}
+
+	return writeCacheFile(cacheFilePath, p.Cache)
+}
+
+func (p *Project) mergeCacheFromFile(cacheFilePath string) {
+	var cacheData Cache
+	if err := readCacheFromFile(cacheFilePath, &cacheData); err != nil || migrateCache(&cacheData) != nil {
+		return
+	}
+
+	for target, metadata := range cacheData.Target {
+		if target != p.CMDOptions.Target && metadata != nil {
+			p.Cache.Target[target] = metadata
+		}
+	}
+
+	for target, history := range cacheData.History {
+		if target == p.CMDOptions.Target {
+			continue
+		}
+		if p.Cache.History == nil {
+			p.Cache.History = map[Target][]string{}
+		}
+		p.Cache.History[target] = history
+	}
+}
+
+// lockCacheFile takes the advisory lock of a cache entry, shared by all goac processes.
+func lockCacheFile(cacheFilePath string) (*flock.Lock, error) {
+	dir := filepath.Join(filepath.Dir(cacheFilePath), lockCacheDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating cache directory: %v", err)
+	}
+	return flock.Acquire(filepath.Join(dir, strings.TrimSuffix(filepath.Base(cacheFilePath), ".yaml")+".lock"))
+}
+
+// removeCacheFile removes a cache entry holding its lock, so that it is not removed while it is written.
+func removeCacheFile(cacheFilePath string) error {
+	lock, err := lockCacheFile(cacheFilePath)
+	if err != nil {
+		return err
 	}
+	defer func() { _ = lock.Release() }()
+
+	if err := os.Remove(cacheFilePath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
 
-	cacheData, err := yaml.Marshal(p.Cache)
+func writeCacheFile(cacheFilePath string, cache *Cache) error {
+	cacheData, err := yaml.Marshal(cache)
 	if err != nil {
 		return fmt.Errorf("error encoding yaml data: %v", err)
 	}
 
-	if err := os.WriteFile(cacheFilePath, cacheData, 0o644); err != nil {
+	if err := utils.WriteFileAtomic(cacheFilePath, cacheData, 0o644); err != nil {
 		return fmt.Errorf("error writing cache file %s: %v", cacheFilePath, err)
 	}
 	return nil
 }
+
+func (p *Project) contentCacheFilePath(contentHash string) string {
+	return filepath.Join(p.CMDOptions.cachePath(), contentCacheDir, fmt.Sprintf("%s.yaml", contentHash))
+}
+
+// isContentCached reports if an entry with the same content key exists,
+// whatever the path of the project that wrote it.
+func (p *Project) isContentCached() bool {
+	if p.Metadata == nil || p.Metadata.ContentHash == "" {
+		return false
+	}
+
+	_, err := os.Stat(p.contentCacheFilePath(p.Metadata.ContentHash))
+	return err == nil
+}
+
+// writeContentCache writes the content entry and records it in the project history,
+// removing the entries of this project that fall out of the history.
+func (p *Project) writeContentCache(metadata *Metadata) error {
+	if err := os.MkdirAll(filepath.Join(p.CMDOptions.cachePath(), contentCacheDir), 0o755); err != nil {
+		return fmt.Errorf("error creating cache directory: %v", err)
+	}
+
+	// content entries are immutable for a given key, the lock only keeps them from being removed while written
+	contentFilePath := p.contentCacheFilePath(metadata.ContentHash)
+	lock, err := lockCacheFile(contentFilePath)
+	if err != nil {
+		return err
+	}
+	err = writeCacheFile(contentFilePath, &Cache{
+		Version: CacheFormatVersion,
+		Name:    p.Name,
+		Path:    p.CleanPath,
+		Target:  map[Target]*Metadata{p.CMDOptions.Target: metadata},
+	})
+	_ = lock.Release()
+	if err != nil {
+		return err
+	}
+
+	if p.Cache.History == nil {
+		p.Cache.History = map[Target][]string{}
+	}
+
+	history := []string{metadata.ContentHash}
+	for _, contentHash := range p.Cache.History[p.CMDOptions.Target] {
+		if contentHash != metadata.ContentHash {
+			history = append(history, contentHash)
+		}
+	}
+
+	if len(history) > DefaultCacheHistory {
+		for _, contentHash := range history[DefaultCacheHistory:] {
+			p.removeContentCache(contentHash)
+		}
+		history = history[:DefaultCacheHistory]
+	}
+	p.Cache.History[p.CMDOptions.Target] = history
+
+	return nil
+}
+
+// removeContentCache removes a content entry only if it was written by this project,
+// entries shared with another project path are kept.
+func (p *Project) removeContentCache(contentHash string) {
+	contentFilePath := p.contentCacheFilePath(contentHash)
+	lock, err := lockCacheFile(contentFilePath)
+	if err != nil {
+		return
+	}
+	defer func() { _ = lock.Release() }()
+
+	var contentData Cache
+	if err := readCacheFromFile(contentFilePath, &contentData); err != nil || contentData.Path != p.CleanPath {
+		return
+	}
+
+	_ = os.Remove(contentFilePath)
+}
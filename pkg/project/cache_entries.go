@@ -0,0 +1,416 @@
+package project
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/kperreau/goac/pkg/logger"
+	"gopkg.in/yaml.v3"
+)
+
+// CacheEntry is a cache file stored in the cache directory, one per project path.
+type CacheEntry struct {
+	Key   string
+	File  string
+	Cache *Cache
+}
+
+type CachePruneOptions struct {
+	OlderThan time.Duration
+	DryRun    bool
+}
+
+type CacheCleanOptions struct {
+	ProjectsName []string
+	Tags         []string
+	Paths        []string
+	Exclude      []string
+	Target       Target
+	DryRun       bool
+}
+
+func (o *CacheCleanOptions) selector() *Selector {
+	return &Selector{Names: o.ProjectsName, Tags: o.Tags, Paths: o.Paths, Exclude: o.Exclude}
+}
+
+// LoadCacheEntries reads every cache file from the cache directory, sorted by project path.
+func LoadCacheEntries(cachePath string) ([]*CacheEntry, error) {
+	return loadCacheEntries(cachePath)
+}
+
+// LoadContentCacheEntries reads every content cache file of the cache directory, keyed by content hash,
+// sorted by project path.
+func LoadContentCacheEntries(cachePath string) ([]*CacheEntry, error) {
+	return loadCacheEntries(filepath.Join(cachePath, contentCacheDir))
+}
+
+func loadCacheEntries(dir string) ([]*CacheEntry, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return nil, fmt.Errorf("error listing cache files: %w", err)
+	}
+
+	entries := make([]*CacheEntry, 0, len(files))
+	for _, file := range files {
+		cache := Cache{Target: map[Target]*Metadata{}}
+		if err := readCacheFromFile(file, &cache); err != nil {
+			logger.Warnf("ignoring cache file %s: %s\n", file, err)
+			continue
+		}
+		if cache.Target == nil {
+			cache.Target = map[Target]*Metadata{}
+		}
+
+		entries = append(entries, &CacheEntry{
+			Key:   strings.TrimSuffix(filepath.Base(file), ".yaml"),
+			File:  file,
+			Cache: &cache,
+		})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Cache.Path < entries[j].Cache.Path })
+
+	return entries, nil
+}
+
+// ProjectName returns the name stored in the cache entry, or the one read from the
+// project config for entries written before the name was recorded.
+func (e *CacheEntry) ProjectName() string {
+	if e.Cache.Name != "" {
+		return e.Cache.Name
+	}
+
+	project := e.projectConfig()
+	if project == nil || project.Name == "" {
+		return "unknown"
+	}
+
+	return project.Name
+}
+
+// projectTags returns the tags of the project config, cache entries do not record them.
+func (e *CacheEntry) projectTags() []string {
+	if project := e.projectConfig(); project != nil {
+		return project.Tags
+	}
+	return nil
+}
+
+func (e *CacheEntry) projectConfig() *Project {
+	data, err := os.ReadFile(filepath.Join(e.Cache.Path, configFileName))
+	if err != nil {
+		return nil
+	}
+
+	var project Project
+	if err := yaml.Unmarshal(data, &project); err != nil {
+		return nil
+	}
+
+	return &project
+}
+
+// selectable returns the project of the cache entry as known by the selector.
+func (e *CacheEntry) selectable() selectable {
+	return selectable{name: e.ProjectName(), path: e.Cache.Path, tags: e.projectTags()}
+}
+
+func isSelected(s *Selector, p selectable) bool {
+	return s.Match(p.name, p.path, p.tags)
+}
+
+func (e *CacheEntry) projectExists() bool {
+	_, err := os.Stat(filepath.Join(e.Cache.Path, configFileName))
+	return err == nil
+}
+
+func (e *CacheEntry) targets() []Target {
+	targets := make([]Target, 0, len(e.Cache.Target))
+	for target := range e.Cache.Target {
+		targets = append(targets, target)
+	}
+	slices.Sort(targets)
+	return targets
+}
+
+// isOlderThan reports if every target of the entry was built before the duration.
+func (e *CacheEntry) isOlderThan(d time.Duration) bool {
+	for _, metadata := range e.Cache.Target {
+		if !isDateOlderThan(metadata.Date, d) {
+			return false
+		}
+	}
+	return true
+}
+
+func isDateOlderThan(date string, d time.Duration) bool {
+	t, err := time.Parse(time.RFC3339, date)
+	return err != nil || time.Since(t) > d
+}
+
+func (e *CacheEntry) save() error {
+	lock, err := lockCacheFile(e.File)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = lock.Release() }()
+
+	if len(e.Cache.Target) == 0 {
+		return os.Remove(e.File)
+	}
+
+	return writeCacheFile(e.File, e.Cache)
+}
+
+// CacheList prints every cache entry of the cache directory with its project name, path, target and date.
+func CacheList(cachePath string) error {
+	entries, err := LoadCacheEntries(cachePath)
+	if err != nil {
+		return err
+	}
+
+	logger.Printf("Found %s cache entries\n", color.YellowString("%d", len(entries)))
+	for _, entry := range entries {
+		name := entry.ProjectName()
+		if len(entry.Cache.Target) == 0 {
+			logger.Printf("%s %s %s\n", color.BlueString(name), color.YellowString("=>"), entry.Cache.Path)
+			continue
+		}
+		for _, target := range entry.targets() {
+			logger.Printf("%s %s %s [%s] %s\n", color.BlueString(name), color.YellowString("=>"), entry.Cache.Path,
+				target, color.HiBlackString(entry.Cache.Target[target].Date))
+		}
+	}
+
+	contentEntries, err := LoadContentCacheEntries(cachePath)
+	if err != nil {
+		return err
+	}
+	logger.Printf("Found %s content entries\n", color.YellowString("%d", len(contentEntries)))
+
+	return nil
+}
+
+// CacheShow prints the cache entry of the cache directory matching a project name, a project path or a cache key.
+func CacheShow(cachePath string, ref string) error {
+	entries, err := LoadCacheEntries(cachePath)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.Key != ref && entry.Cache.Path != filepath.Clean(ref) && entry.ProjectName() != ref {
+			continue
+		}
+
+		data, err := os.ReadFile(entry.File)
+		if err != nil {
+			return err
+		}
+		logger.Printf("%s %s\n%s\n", color.YellowString("#"), entry.File, strings.TrimSpace(string(data)))
+		return nil
+	}
+
+	return fmt.Errorf("no cache entry found for %s", ref)
+}
+
+// orphanContentAge is the age after which a content entry referenced by no history is pruned.
+// Younger entries may be written by a build that did not record them in its history yet.
+const orphanContentAge = time.Hour
+
+// CachePrune removes entries whose project no longer exists, and targets built before OlderThan.
+// Content entries are not tied to a project path, they are pruned by date or when no history
+// references them anymore, like those of the invalidated targets.
+func CachePrune(cachePath string, opts *CachePruneOptions) error {
+	entries, err := LoadCacheEntries(cachePath)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	removed := 0
+	referenced := map[string]bool{}
+	for _, entry := range entries {
+		if !entry.projectExists() {
+			logger.Printf("%s %s %s [%s]\n", color.BlueString(entry.ProjectName()), color.YellowString("=>"), entry.Cache.Path, color.RedString("Missing"))
+			removed += len(entry.Cache.Target)
+			if !opts.DryRun {
+				errs = append(errs, removeCacheFile(entry.File))
+			}
+			continue
+		}
+
+		for _, history := range entry.Cache.History {
+			for _, contentHash := range history {
+				referenced[contentHash] = true
+			}
+		}
+
+		if opts.OlderThan <= 0 {
+			continue
+		}
+
+		changed := false
+		for _, target := range entry.targets() {
+			if !isDateOlderThan(entry.Cache.Target[target].Date, opts.OlderThan) {
+				continue
+			}
+			logger.Printf("%s %s %s [%s] %s\n", color.BlueString(entry.ProjectName()), color.YellowString("=>"), entry.Cache.Path,
+				target, color.HiBlackString("Expired"))
+			delete(entry.Cache.Target, target)
+			removed++
+			changed = true
+		}
+
+		if changed && !opts.DryRun {
+			errs = append(errs, entry.save())
+		}
+	}
+
+	contentEntries, err := LoadContentCacheEntries(cachePath)
+	if err != nil {
+		return err
+	}
+	for _, entry := range contentEntries {
+		status := "Expired"
+		if opts.OlderThan <= 0 || !entry.isOlderThan(opts.OlderThan) {
+			if referenced[entry.Key] || !entry.isOlderThan(orphanContentAge) {
+				continue
+			}
+			status = "Unreferenced"
+		}
+		for _, target := range entry.targets() {
+			logger.Printf("%s %s %s [%s] %s %s\n", color.BlueString(entry.ProjectName()), color.YellowString("=>"), entry.Cache.Path,
+				target, color.HiBlackString(entry.Key), color.HiBlackString(status))
+		}
+		removed++
+		if !opts.DryRun {
+			errs = append(errs, removeCacheFile(entry.File))
+		}
+	}
+
+	logger.Printf("Pruned %s cache entries\n", color.YellowString("%d", removed))
+
+	return errors.Join(errs...)
+}
+
+// CacheClean removes the cache of the given projects and target, or everything if none are set.
+func CacheClean(cachePath string, opts *CacheCleanOptions) error {
+	selector := opts.selector()
+	if err := selector.Validate(); err != nil {
+		return err
+	}
+
+	entries, err := LoadCacheEntries(cachePath)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	var found []selectable
+	removed := 0
+	for _, entry := range entries {
+		project := entry.selectable()
+		found = append(found, project)
+		if !isSelected(selector, project) {
+			continue
+		}
+
+		changed := len(entry.Cache.Target) == 0
+		for _, target := range entry.targets() {
+			if opts.Target != TargetNone && opts.Target != target {
+				continue
+			}
+			logger.Printf("%s %s %s [%s]\n", color.BlueString(project.name), color.YellowString("=>"), entry.Cache.Path, target)
+			delete(entry.Cache.Target, target)
+			removed++
+			changed = true
+		}
+
+		if changed && !opts.DryRun {
+			errs = append(errs, entry.save())
+		}
+	}
+
+	contentEntries, err := LoadContentCacheEntries(cachePath)
+	if err != nil {
+		return err
+	}
+	for _, entry := range contentEntries {
+		project := entry.selectable()
+		found = append(found, project)
+		if !isSelected(selector, project) {
+			continue
+		}
+		if opts.Target != TargetNone && entry.Cache.Target[opts.Target] == nil {
+			continue
+		}
+		for _, target := range entry.targets() {
+			logger.Printf("%s %s %s [%s] %s\n", color.BlueString(project.name), color.YellowString("=>"), entry.Cache.Path,
+				target, color.HiBlackString(entry.Key))
+		}
+		removed++
+		if !opts.DryRun {
+			errs = append(errs, removeCacheFile(entry.File))
+		}
+	}
+
+	selector.warnUnmatched(logger.Default(), found)
+	logger.Printf("Cleaned %s cache entries\n", color.YellowString("%d", removed))
+
+	return errors.Join(errs...)
+}
+
+type cacheStatus string
+
+const (
+	cacheStatusValid   cacheStatus = "valid"
+	cacheStatusStale   cacheStatus = "stale"
+	cacheStatusMissing cacheStatus = "missing"
+)
+
+func (p *Project) cacheStatus() cacheStatus {
+	cached := p.Cache.Target[p.CMDOptions.Target]
+	if cached == nil {
+		return cacheStatusMissing
+	}
+	if !cached.isMetadataMatch(p.Metadata) {
+		return cacheStatusStale
+	}
+	return cacheStatusValid
+}
+
+// VerifyCache compares the cache entries of the target with freshly computed hashes.
+func (l *List) VerifyCache() error {
+	stale := 0
+	for _, p := range l.Projects {
+		status := p.cacheStatus()
+		logger.Printf("%s %s %s [%s]\n", color.BlueString(p.Name), color.YellowString("=>"), p.CleanPath, printCacheStatus(status))
+		if status == cacheStatusStale {
+			stale++
+		}
+	}
+
+	if stale > 0 {
+		return fmt.Errorf("%d stale cache entries for target %s", stale, l.Options.Target)
+	}
+
+	return nil
+}
+
+func printCacheStatus(s cacheStatus) string {
+	switch s {
+	case cacheStatusValid:
+		return color.GreenString("Valid")
+	case cacheStatusStale:
+		return color.RedString("Stale")
+	}
+	return color.HiBlackString("Missing")
+}
@@ -0,0 +1,365 @@
+package project
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kperreau/goac/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func writeCacheEntry(t *testing.T, dir string, key string, cache *Cache) string {
+	data, err := yaml.Marshal(cache)
+	assert.NoError(t, err)
+
+	file := filepath.Join(dir, key+".yaml")
+	assert.NoError(t, os.WriteFile(file, data, 0o644))
+
+	return file
+}
+
+func TestLoadCacheEntries_SortedByPath(t *testing.T) {
+	// Arrange
+	tmpDir := t.TempDir()
+
+	writeCacheEntry(t, tmpDir, "key2", &Cache{Name: "b", Path: "services/b", Target: map[Target]*Metadata{}})
+	writeCacheEntry(t, tmpDir, "key1", &Cache{Name: "a", Path: "services/a", Target: map[Target]*Metadata{}})
+
+	// Act
+	entries, err := LoadCacheEntries(tmpDir)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "key1", entries[0].Key)
+	assert.Equal(t, "services/a", entries[0].Cache.Path)
+	assert.Equal(t, "key2", entries[1].Key)
+}
+
+func TestLoadCacheEntries_SkipsCorruptedEntries(t *testing.T) {
+	// Arrange
+	tmpDir := t.TempDir()
+
+	writeCacheEntry(t, tmpDir, "valid", &Cache{Name: "a", Path: "services/a", Target: map[Target]*Metadata{}})
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "corrupted.yaml"), []byte("target:\n    invalid"), 0o644))
+
+	// Act
+	var entries []*CacheEntry
+	_, err := redirectAffectedStdout(func() (err error) {
+		entries, err = LoadCacheEntries(tmpDir)
+		return err
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "valid", entries[0].Key)
+}
+
+func TestCacheEntryProjectName_FallbackToProjectConfig(t *testing.T) {
+	// Arrange
+	entry := &CacheEntry{Cache: &Cache{Path: "../.."}}
+
+	// Act
+	name := entry.ProjectName()
+
+	// Assert
+	assert.Equal(t, "goac", name)
+}
+
+func TestCacheEntryProjectName_UnknownProject(t *testing.T) {
+	// Arrange
+	entry := &CacheEntry{Cache: &Cache{Path: "invalid-path"}}
+
+	// Act
+	name := entry.ProjectName()
+
+	// Assert
+	assert.Equal(t, "unknown", name)
+}
+
+func TestCachePrune_RemovesMissingProjectsAndExpiredTargets(t *testing.T) {
+	// Arrange
+	tmpDir := t.TempDir()
+
+	missing := writeCacheEntry(t, tmpDir, "missing", &Cache{
+		Name:   "missing",
+		Path:   "invalid-path",
+		Target: map[Target]*Metadata{TargetBuild: {Date: time.Now().Format(time.RFC3339)}},
+	})
+	existing := writeCacheEntry(t, tmpDir, "goac", &Cache{
+		Name: "goac",
+		Path: "../..",
+		Target: map[Target]*Metadata{
+			TargetBuild:      {Date: time.Now().Format(time.RFC3339)},
+			TargetBuildImage: {Date: time.Now().Add(-48 * time.Hour).Format(time.RFC3339)},
+		},
+	})
+
+	// Act
+	_, err := redirectAffectedStdout(func() error { return CachePrune(tmpDir, &CachePruneOptions{OlderThan: 24 * time.Hour}) })
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NoFileExists(t, missing)
+
+	cache := Cache{}
+	assert.NoError(t, readCacheFromFile(existing, &cache))
+	assert.Contains(t, cache.Target, TargetBuild)
+	assert.NotContains(t, cache.Target, TargetBuildImage)
+}
+
+func TestCachePrune_DryRunKeepsFiles(t *testing.T) {
+	// Arrange
+	tmpDir := t.TempDir()
+
+	missing := writeCacheEntry(t, tmpDir, "missing", &Cache{Path: "invalid-path", Target: map[Target]*Metadata{TargetBuild: {}}})
+
+	// Act
+	output, err := redirectAffectedStdout(func() error { return CachePrune(tmpDir, &CachePruneOptions{DryRun: true}) })
+
+	// Assert
+	assert.NoError(t, err)
+	assert.FileExists(t, missing)
+	assert.Contains(t, output.String(), "Pruned 1 cache entries")
+}
+
+func TestCachePrune_RemovesUnreferencedContentEntries(t *testing.T) {
+	// Arrange
+	tmpDir := t.TempDir()
+	contentDir := filepath.Join(tmpDir, contentCacheDir)
+	assert.NoError(t, os.MkdirAll(contentDir, 0o755))
+	old := time.Now().Add(-2 * time.Hour).Format(time.RFC3339)
+	writeCacheEntry(t, tmpDir, "goac", &Cache{
+		Name:    "goac",
+		Path:    "../..",
+		Target:  map[Target]*Metadata{TargetBuild: {Date: old}},
+		History: map[Target][]string{TargetBuild: {"kept"}},
+	})
+	kept := writeCacheEntry(t, contentDir, "kept", &Cache{Path: "../..", Target: map[Target]*Metadata{TargetBuild: {Date: old}}})
+	orphan := writeCacheEntry(t, contentDir, "orphan", &Cache{Path: "../..", Target: map[Target]*Metadata{TargetBuild: {Date: old}}})
+	recent := writeCacheEntry(t, contentDir, "recent", &Cache{
+		Path:   "../..",
+		Target: map[Target]*Metadata{TargetBuild: {Date: time.Now().Format(time.RFC3339)}},
+	})
+
+	// Act
+	output, err := redirectAffectedStdout(func() error { return CachePrune(tmpDir, &CachePruneOptions{}) })
+
+	// Assert
+	assert.NoError(t, err)
+	assert.FileExists(t, kept)
+	assert.NoFileExists(t, orphan)
+	assert.FileExists(t, recent)
+	assert.Contains(t, output.String(), "[build] orphan Unreferenced")
+	assert.Contains(t, output.String(), "Pruned 1 cache entries")
+}
+
+func TestCacheClean_ByProjectAndTarget(t *testing.T) {
+	// Arrange
+	tmpDir := t.TempDir()
+
+	a := writeCacheEntry(t, tmpDir, "a", &Cache{Name: "a", Path: "a", Target: map[Target]*Metadata{TargetBuild: {}, TargetBuildImage: {}}})
+	b := writeCacheEntry(t, tmpDir, "b", &Cache{Name: "b", Path: "b", Target: map[Target]*Metadata{TargetBuild: {}}})
+
+	// Act
+	_, err := redirectAffectedStdout(func() error {
+		return CacheClean(tmpDir, &CacheCleanOptions{ProjectsName: []string{"a"}, Target: TargetBuild})
+	})
+
+	// Assert
+	assert.NoError(t, err)
+
+	cache := Cache{}
+	assert.NoError(t, readCacheFromFile(a, &cache))
+	assert.NotContains(t, cache.Target, TargetBuild)
+	assert.Contains(t, cache.Target, TargetBuildImage)
+	assert.FileExists(t, b)
+}
+
+func TestCacheClean_AllRemovesFiles(t *testing.T) {
+	// Arrange
+	tmpDir := t.TempDir()
+
+	a := writeCacheEntry(t, tmpDir, "a", &Cache{Name: "a", Path: "a", Target: map[Target]*Metadata{TargetBuild: {}}})
+
+	// Act
+	_, err := redirectAffectedStdout(func() error { return CacheClean(tmpDir, &CacheCleanOptions{Target: TargetNone}) })
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NoFileExists(t, a)
+}
+
+func TestCacheClean_LeavesNoLockFileNextToEntries(t *testing.T) {
+	// Arrange
+	tmpDir := t.TempDir()
+
+	writeCacheEntry(t, tmpDir, "a", &Cache{Name: "a", Path: "a", Target: map[Target]*Metadata{TargetBuild: {}}})
+
+	// Act
+	_, err := redirectAffectedStdout(func() error { return CacheClean(tmpDir, &CacheCleanOptions{Target: TargetNone}) })
+
+	// Assert
+	assert.NoError(t, err)
+	files, _ := os.ReadDir(tmpDir)
+	for _, file := range files {
+		assert.True(t, file.IsDir(), "unexpected file %s", file.Name())
+	}
+}
+
+func TestCacheClean_DryRunReportsContentEntries(t *testing.T) {
+	// Arrange
+	tmpDir := t.TempDir()
+	contentDir := filepath.Join(tmpDir, contentCacheDir)
+	assert.NoError(t, os.MkdirAll(contentDir, 0o755))
+	writeCacheEntry(t, tmpDir, "a", &Cache{Name: "a", Path: "a", Target: map[Target]*Metadata{TargetBuild: {}}})
+	content := writeCacheEntry(t, contentDir, "abc", &Cache{Name: "a", Path: "a", Target: map[Target]*Metadata{TargetBuild: {}}})
+
+	// Act
+	output, err := redirectAffectedStdout(func() error {
+		return CacheClean(tmpDir, &CacheCleanOptions{Target: TargetNone, DryRun: true})
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.FileExists(t, content)
+	assert.Equal(t, "a => a [build]\na => a [build] abc\nCleaned 2 cache entries\n", output.String())
+}
+
+func TestCacheClean_WaitsForTheLockOfContentEntries(t *testing.T) {
+	// Arrange
+	tmpDir := t.TempDir()
+
+	contentDir := filepath.Join(tmpDir, contentCacheDir)
+	assert.NoError(t, os.MkdirAll(contentDir, 0o755))
+	content := writeCacheEntry(t, contentDir, "abc", &Cache{Name: "a", Path: "a", Target: map[Target]*Metadata{TargetBuild: {}}})
+	lock, err := lockCacheFile(content)
+	assert.NoError(t, err)
+
+	// Act
+	done := make(chan error)
+	go func() {
+		_, err := redirectAffectedStdout(func() error { return CacheClean(tmpDir, &CacheCleanOptions{Target: TargetNone}) })
+		done <- err
+	}()
+	time.Sleep(100 * time.Millisecond)
+	_, statErr := os.Stat(content)
+	assert.NoError(t, lock.Release())
+
+	// Assert
+	assert.NoError(t, <-done)
+	assert.NoError(t, statErr, "removed while locked")
+	assert.NoFileExists(t, content)
+}
+
+func TestCacheShow_NotFound(t *testing.T) {
+	// Arrange
+	tmpDir := t.TempDir()
+
+	// Act
+	err := CacheShow(tmpDir, "not-found")
+
+	// Assert
+	assert.Error(t, err)
+}
+
+func TestVerifyCache_ReturnsErrorOnStaleEntries(t *testing.T) {
+	// Arrange
+	opts := &Options{Target: TargetBuild}
+	l := &List{
+		Projects: []*Project{
+			{
+				Name: "valid", CleanPath: "valid", CMDOptions: opts,
+				Metadata: &Metadata{DependenciesHash: "a", DirHash: "b"},
+				Cache:    &Cache{Target: map[Target]*Metadata{TargetBuild: {DependenciesHash: "a", DirHash: "b"}}},
+			},
+			{
+				Name: "stale", CleanPath: "stale", CMDOptions: opts,
+				Metadata: &Metadata{DependenciesHash: "a", DirHash: "c"},
+				Cache:    &Cache{Target: map[Target]*Metadata{TargetBuild: {DependenciesHash: "a", DirHash: "b"}}},
+			},
+			{
+				Name: "missing", CleanPath: "missing", CMDOptions: opts,
+				Metadata: &Metadata{DependenciesHash: "a", DirHash: "b"},
+				Cache:    &Cache{Target: map[Target]*Metadata{}},
+			},
+		},
+		Options: opts,
+	}
+
+	// Act
+	output, err := redirectAffectedStdout(l.VerifyCache)
+
+	// Assert
+	assert.Error(t, err)
+	assert.Equal(t, "valid => valid [Valid]\nstale => stale [Stale]\nmissing => missing [Missing]\n", output.String())
+}
+
+func TestVerifyCache_WarnsUnmatchedSelector(t *testing.T) {
+	// Arrange
+	var stdout, stderr bytes.Buffer
+	opt := &Options{
+		Target:         TargetBuild,
+		DryRun:         true,
+		MaxConcurrency: 2,
+		ProjectsName:   []string{"goca"},
+		RootPath:       "../..",
+		NoDaemon:       true,
+		Logger:         newTestLogger(logger.LevelInfo, &stdout, &stderr),
+	}
+
+	// Act
+	l, err := NewProjectsList(context.Background(), opt)
+	assert.NoError(t, err)
+	err = l.VerifyCache()
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Empty(t, stdout.String())
+	assert.Contains(t, stderr.String(), "no project matches \"goca\", did you mean goac?")
+}
+
+func TestCacheClean_WarnsUnmatchedSelector(t *testing.T) {
+	// Arrange
+	tmpDir := t.TempDir()
+	writeCacheEntry(t, tmpDir, "auth", &Cache{Name: "auth", Path: "services/auth", Target: map[Target]*Metadata{TargetBuild: {}}})
+
+	var stdout, stderr bytes.Buffer
+	oldLogger := logger.Default()
+	logger.SetDefault(newTestLogger(logger.LevelInfo, &stdout, &stderr))
+	defer logger.SetDefault(oldLogger)
+
+	// Act
+	err := CacheClean(tmpDir, &CacheCleanOptions{ProjectsName: []string{"auht"}, Target: TargetNone, DryRun: true})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "Cleaned 0 cache entries\n", stdout.String())
+	assert.Equal(t, "no project matches \"auht\", did you mean auth?\n", stderr.String())
+}
+
+func TestCacheClean_ByTag(t *testing.T) {
+	// Arrange
+	tmpDir := t.TempDir()
+
+	projectDir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(projectDir, configFileName), []byte("name: tagged\ntags: [backend]\n"), 0o644))
+	tagged := writeCacheEntry(t, tmpDir, "tagged", &Cache{Name: "tagged", Path: projectDir, Target: map[Target]*Metadata{TargetBuild: {}}})
+	other := writeCacheEntry(t, tmpDir, "other", &Cache{Name: "other", Path: "other", Target: map[Target]*Metadata{TargetBuild: {}}})
+
+	// Act
+	_, err := redirectAffectedStdout(func() error {
+		return CacheClean(tmpDir, &CacheCleanOptions{Tags: []string{"backend"}, Target: TargetNone})
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NoFileExists(t, tagged)
+	assert.FileExists(t, other)
+}
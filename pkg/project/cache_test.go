@@ -4,8 +4,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/kperreau/goac/pkg/hasher"
 	"github.com/stretchr/testify/assert"
 	"gopkg.in/yaml.v3"
 )
@@ -27,13 +30,16 @@ func TestLoadCache_LoadsCacheDataFromFileIfExists(t *testing.T) {
 	defer os.RemoveAll(tmpDir)
 
 	// Create a temporary cache file
-	cacheFilePath := fmt.Sprintf("%s%s.yaml", DefaultCachePath, p.HashPath)
+	cacheFilePath := filepath.Join(DefaultCachePath, p.HashPath+".yaml")
 	cacheData := Cache{
-		Path: p.CleanPath,
+		Version: CacheFormatVersion,
+		Path:    p.CleanPath,
 		Target: map[Target]*Metadata{
 			TargetBuild: {
 				DependenciesHash: "hash",
 				DirHash:          "hash",
+				Algorithm:        hasher.SHA1,
+				Scheme:           HashSchemeVersion,
 				Date:             "date",
 			},
 		},
@@ -71,7 +77,7 @@ func TestLoadCache_InitializesDefaultCacheIfFileDoesNotExist(t *testing.T) {
 	assert.Empty(t, p.Cache.Target)
 }
 
-func TestLoadCache_ReturnsErrorIfCacheFileCannotBeRead(t *testing.T) {
+func TestLoadCache_UnreadableCacheFileIsAMiss(t *testing.T) {
 	// Initialize the class object
 	p := &Project{
 		Cache:     &Cache{},
@@ -88,18 +94,19 @@ func TestLoadCache_ReturnsErrorIfCacheFileCannotBeRead(t *testing.T) {
 	defer os.RemoveAll(tmpDir)
 
 	// Create a cache file with no read permissions
-	cacheFilePath := fmt.Sprintf("%s%s.yaml", DefaultCachePath, p.HashPath)
+	cacheFilePath := filepath.Join(DefaultCachePath, p.HashPath+".yaml")
 	err = os.WriteFile(cacheFilePath, []byte{}, 0o000)
 	assert.NoError(t, err)
 
 	// Call the method under test
 	err = p.LoadCache()
 
-	// Assert that an error is returned
-	assert.Error(t, err)
+	// Assert that the cache is ignored instead of failing
+	assert.NoError(t, err)
+	assert.Empty(t, p.Cache.Target)
 }
 
-func TestLoadCache_ReturnsErrorIfCacheFileCannotBeUnmarshaled(t *testing.T) {
+func TestLoadCache_CorruptedCacheFileIsAMiss(t *testing.T) {
 	// Initialize the class object
 	p := &Project{
 		Cache:     &Cache{},
@@ -116,15 +123,17 @@ func TestLoadCache_ReturnsErrorIfCacheFileCannotBeUnmarshaled(t *testing.T) {
 	defer os.RemoveAll(tmpDir)
 
 	// Create a cache file with invalid YAML data
-	cacheFilePath := fmt.Sprintf("%s%s.yaml", DefaultCachePath, p.HashPath)
+	cacheFilePath := filepath.Join(DefaultCachePath, p.HashPath+".yaml")
 	err = os.WriteFile(cacheFilePath, []byte("invalid_yaml"), 0o644)
 	assert.NoError(t, err)
 
 	// Call the method under test
 	err = p.LoadCache()
 
-	// Assert that an error is returned
-	assert.Error(t, err)
+	// Assert that the cache is ignored instead of failing
+	assert.NoError(t, err)
+	assert.Equal(t, p.CleanPath, p.Cache.Path)
+	assert.Empty(t, p.Cache.Target)
 }
 
 func TestReadCacheFromFile_SuccessfulRead(t *testing.T) {
@@ -302,3 +311,235 @@ func TestWriteCache_ErrorCreatingCacheDirectory(t *testing.T) {
 	// Assert that the expected error is returned
 	assert.Error(t, err)
 }
+
+func TestWriteCache_WritesContentEntryAndHistory(t *testing.T) {
+	// Arrange
+	tmpDir := t.TempDir()
+	OldDefaultCachePath := DefaultCachePath
+	DefaultCachePath = tmpDir
+	defer func() { DefaultCachePath = OldDefaultCachePath }()
+
+	p := &Project{
+		Name:      "goac",
+		CleanPath: "services/goac",
+		HashPath:  "hash",
+		Cache:     &Cache{Target: make(map[Target]*Metadata)},
+		CMDOptions: &Options{
+			Target: TargetBuild,
+		},
+		Metadata: &Metadata{
+			DependenciesHash: "dependenciesHash",
+			DirHash:          "dirHash",
+			ContentHash:      "contentHash",
+		},
+	}
+
+	// Act
+	err := p.writeCache()
+
+	// Assert
+	assert.NoError(t, err)
+	assert.FileExists(t, p.contentCacheFilePath("contentHash"))
+	assert.Equal(t, []string{"contentHash"}, p.Cache.History[TargetBuild])
+	assert.True(t, p.isContentCached())
+}
+
+func TestWriteCache_HistoryRemovesOldestOwnContentEntries(t *testing.T) {
+	// Arrange
+	tmpDir := t.TempDir()
+	OldDefaultCachePath := DefaultCachePath
+	DefaultCachePath = tmpDir
+	OldDefaultCacheHistory := DefaultCacheHistory
+	DefaultCacheHistory = 2
+	defer func() {
+		DefaultCachePath = OldDefaultCachePath
+		DefaultCacheHistory = OldDefaultCacheHistory
+	}()
+
+	p := &Project{
+		Name:       "goac",
+		CleanPath:  "services/goac",
+		HashPath:   "hash",
+		Cache:      &Cache{Target: make(map[Target]*Metadata)},
+		CMDOptions: &Options{Target: TargetBuild},
+	}
+
+	// Act
+	for _, contentHash := range []string{"first", "second", "third"} {
+		p.Metadata = &Metadata{DependenciesHash: "deps", DirHash: contentHash, ContentHash: contentHash}
+		assert.NoError(t, p.writeCache())
+	}
+
+	// Assert
+	assert.Equal(t, []string{"third", "second"}, p.Cache.History[TargetBuild])
+	assert.NoFileExists(t, p.contentCacheFilePath("first"))
+	assert.FileExists(t, p.contentCacheFilePath("second"))
+}
+
+func TestIsContentCached_NoContentHash(t *testing.T) {
+	// Arrange
+	p := &Project{Metadata: &Metadata{DependenciesHash: "a", DirHash: "b"}}
+
+	// Act
+	result := p.isContentCached()
+
+	// Assert
+	assert.False(t, result)
+}
+
+func TestWriteCache_KeepsTargetsWrittenByAnotherProcess(t *testing.T) {
+	// Arrange
+	tmpDir := t.TempDir()
+	OldDefaultCachePath := DefaultCachePath
+	DefaultCachePath = tmpDir
+	defer func() { DefaultCachePath = OldDefaultCachePath }()
+
+	build := &Project{
+		Name:       "goac",
+		HashPath:   "hash",
+		Cache:      &Cache{Target: make(map[Target]*Metadata)},
+		CMDOptions: &Options{Target: TargetBuild},
+		Metadata:   &Metadata{DependenciesHash: "deps", DirHash: "dir"},
+	}
+	image := &Project{
+		Name:       "goac",
+		HashPath:   "hash",
+		Cache:      &Cache{Target: make(map[Target]*Metadata)},
+		CMDOptions: &Options{Target: TargetBuildImage},
+		Metadata:   &Metadata{DependenciesHash: "deps", DirHash: "dir"},
+	}
+
+	// Act
+	assert.NoError(t, build.writeCache())
+	assert.NoError(t, image.writeCache())
+
+	// Assert
+	cache := Cache{}
+	assert.NoError(t, readCacheFromFile(filepath.Join(tmpDir, "hash.yaml"), &cache))
+	assert.Contains(t, cache.Target, TargetBuild)
+	assert.Contains(t, cache.Target, TargetBuildImage)
+}
+
+func TestWriteCache_ConcurrentWritesKeepValidYAML(t *testing.T) {
+	// Arrange
+	tmpDir := t.TempDir()
+	OldDefaultCachePath := DefaultCachePath
+	DefaultCachePath = tmpDir
+	defer func() { DefaultCachePath = OldDefaultCachePath }()
+
+	// Act
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(target Target) {
+			defer wg.Done()
+			p := &Project{
+				Name:       "goac",
+				HashPath:   "hash",
+				Cache:      &Cache{Target: make(map[Target]*Metadata)},
+				CMDOptions: &Options{Target: target},
+				Metadata:   &Metadata{DependenciesHash: "deps", DirHash: "dir"},
+			}
+			assert.NoError(t, p.writeCache())
+		}(Target(fmt.Sprintf("target-%d", i)))
+	}
+	wg.Wait()
+
+	// Assert
+	cache := Cache{}
+	assert.NoError(t, readCacheFromFile(filepath.Join(tmpDir, "hash.yaml"), &cache))
+	assert.Len(t, cache.Target, 10)
+}
+
+func TestMigrateCache_LegacyEntriesAreSHA1FirstScheme(t *testing.T) {
+	// Arrange
+	cache := &Cache{Target: map[Target]*Metadata{TargetBuild: {DependenciesHash: "a", DirHash: "b"}}}
+
+	// Act
+	err := migrateCache(cache)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, CacheFormatVersion, cache.Version)
+	assert.Equal(t, hasher.SHA1, cache.Target[TargetBuild].Algorithm)
+	assert.Equal(t, 1, cache.Target[TargetBuild].Scheme)
+}
+
+func TestMigrateCache_UnsupportedNewerVersion(t *testing.T) {
+	// Arrange
+	cache := &Cache{Version: CacheFormatVersion + 1}
+
+	// Act
+	err := migrateCache(cache)
+
+	// Assert
+	assert.Error(t, err)
+}
+
+func TestCacheInvalidate_DropsOtherAlgorithmsAndSchemes(t *testing.T) {
+	// Arrange
+	cache := &Cache{
+		Target: map[Target]*Metadata{
+			TargetBuild:      {Algorithm: hasher.SHA1, Scheme: HashSchemeVersion},
+			TargetBuildImage: {Algorithm: hasher.SHA256, Scheme: HashSchemeVersion},
+			"old-scheme":     {Algorithm: hasher.SHA256, Scheme: HashSchemeVersion - 1},
+			"semantic":       {Algorithm: hasher.SHA256, Scheme: HashSchemeVersion, Semantic: true},
+		},
+		History: map[Target][]string{TargetBuild: {"a"}, "old-scheme": {"b"}, "semantic": {"c"}},
+	}
+
+	// Act
+	cache.invalidate(hasher.SHA256, false)
+
+	// Assert
+	assert.Len(t, cache.Target, 1)
+	assert.Contains(t, cache.Target, TargetBuildImage)
+	assert.Empty(t, cache.History)
+}
+
+func TestLoadCache_InvalidatesEntriesOfAnotherAlgorithm(t *testing.T) {
+	// Arrange
+	tmpDir := t.TempDir()
+	OldDefaultCachePath := DefaultCachePath
+	DefaultCachePath = tmpDir
+	defer func() { DefaultCachePath = OldDefaultCachePath }()
+
+	p := &Project{
+		HashPath:   "hash",
+		CMDOptions: &Options{Target: TargetBuild, HashAlgorithm: hasher.BLAKE3},
+	}
+	data, _ := yaml.Marshal(Cache{Target: map[Target]*Metadata{TargetBuild: {DependenciesHash: "a", DirHash: "b"}}})
+	assert.NoError(t, os.WriteFile(filepath.Join(tmpDir, "hash.yaml"), data, 0o644))
+
+	// Act
+	err := p.LoadCache()
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Empty(t, p.Cache.Target)
+}
+
+func TestWriteCache_KeepsDurationOfLastBuildOnCacheHit(t *testing.T) {
+	// Arrange
+	tmpDir := t.TempDir()
+	OldDefaultCachePath := DefaultCachePath
+	DefaultCachePath = tmpDir
+	defer func() { DefaultCachePath = OldDefaultCachePath }()
+
+	p := &Project{
+		Name:       "goac",
+		HashPath:   "hash",
+		Cache:      &Cache{Target: map[Target]*Metadata{TargetBuild: {Duration: 90 * time.Second}}},
+		CMDOptions: &Options{Target: TargetBuild},
+		Metadata:   &Metadata{DependenciesHash: "deps", DirHash: "dir"},
+	}
+
+	// Act
+	err := p.writeCache()
+
+	// Assert
+	assert.NoError(t, err)
+	cache := Cache{}
+	assert.NoError(t, readCacheFromFile(filepath.Join(tmpDir, "hash.yaml"), &cache))
+	assert.Equal(t, 90*time.Second, cache.Target[TargetBuild].Duration)
+}
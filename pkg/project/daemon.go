@@ -0,0 +1,399 @@
+package project
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kperreau/goac/pkg/hasher"
+	"github.com/kperreau/goac/pkg/logger"
+	"github.com/kperreau/goac/pkg/watch"
+)
+
+// daemonCookieTimeout is the max time waited for the watcher to report a cookie file.
+const daemonCookieTimeout = 2 * time.Second
+
+const daemonCookiePrefix = "daemon-cookie-"
+
+// DaemonOptions are the settings of a daemon.
+type DaemonOptions struct {
+	MaxConcurrency int
+	// Debounce is the time without changes waited before hashing the changed files.
+	Debounce time.Duration
+	// RootPath is the root of the repository served by the daemon, RootPath by default.
+	RootPath string
+	// CachePath is the cache directory of the projects, relative to RootPath, DefaultCachePath by default.
+	CachePath string
+}
+
+// options returns the options of the projects loaded by the daemon, in its repository.
+func (o *DaemonOptions) options() *Options {
+	return &Options{MaxConcurrency: o.MaxConcurrency, RootPath: o.RootPath, CachePath: o.CachePath, NoDaemon: true}
+}
+
+// daemonKey identifies the projects loaded for a target and hashing options.
+type daemonKey struct {
+	target        Target
+	dockerIgnore  bool
+	hashAlgorithm hasher.Algorithm
+	semanticHash  bool
+}
+
+// daemonState is the projects loaded for a key, with the config files and the go env they were loaded with.
+type daemonState struct {
+	list        *List
+	configFiles []string
+	env         map[string]string
+}
+
+type daemonCall struct {
+	req   *daemonRequest
+	reply chan []byte
+}
+
+type daemon struct {
+	opt     *DaemonOptions
+	watcher watch.Watcher
+	dir     string
+	// environ is the environment of the daemon, go env is read with it on each request
+	// so that the values set with go env -w since the daemon started are used.
+	environ []string
+	states  map[daemonKey]*daemonState
+	calls   chan *daemonCall
+	cookies map[string][]*daemonCall
+	expired chan string
+	cookie  int
+	// done is closed when the daemon stops answering
+	done chan struct{}
+}
+
+// RunDaemon keeps the projects, their modules and their file hashes in memory, updates them on file
+// changes reported by w, and serves them on the daemon socket until ctx is done.
+// Before each answer, the daemon waits for the watcher to report a file it writes, so that the files
+// changed before a request are always hashed again.
+func RunDaemon(ctx context.Context, w watch.Watcher, opt *DaemonOptions) error {
+	if opt.MaxConcurrency < 1 {
+		return fmt.Errorf("max concurrency can't be less than 1")
+	}
+
+	dir, err := filepath.Abs(opt.options().rootPath())
+	if err != nil {
+		return err
+	}
+
+	socket := daemonSocket(opt.options().rootPath())
+	if _, err := callDaemon(ctx, socket, &daemonRequest{Protocol: daemonProtocol, Command: daemonPing}); err == nil {
+		return fmt.Errorf("goac daemon already running on %s", socket)
+	}
+	// the socket of a daemon killed before removing it
+	_ = os.Remove(socket)
+
+	if err := os.MkdirAll(filepath.Dir(socket), 0o755); err != nil {
+		return err
+	}
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(socket)
+	defer listener.Close()
+
+	d := &daemon{
+		opt:     opt,
+		watcher: w,
+		dir:     dir,
+		environ: os.Environ(),
+		states:  map[daemonKey]*daemonState{},
+		calls:   make(chan *daemonCall),
+		cookies: map[string][]*daemonCall{},
+		expired: make(chan string),
+		done:    make(chan struct{}),
+	}
+	defer close(d.done)
+	if err := d.watch(); err != nil {
+		return err
+	}
+
+	go d.accept(listener)
+
+	logger.Infof("goac daemon listening on %s\n", socket)
+	return d.run(ctx)
+}
+
+func (d *daemon) accept(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		go d.serve(conn)
+	}
+}
+
+// serve answers the request of a connection.
+func (d *daemon) serve(conn net.Conn) {
+	defer conn.Close()
+
+	var req daemonRequest
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		return
+	}
+
+	call := &daemonCall{req: &req, reply: make(chan []byte, 1)}
+	select {
+	case d.calls <- call:
+	case <-d.done:
+		return
+	}
+
+	select {
+	case data := <-call.reply:
+		_, _ = conn.Write(data)
+	case <-d.done:
+		// the stop request is answered before the daemon exits
+		select {
+		case data := <-call.reply:
+			_, _ = conn.Write(data)
+		default:
+		}
+	}
+}
+
+// run owns the loaded projects: it hashes the changed files and answers the requests one at a time.
+func (d *daemon) run(ctx context.Context) error {
+	pending := map[string]bool{}
+	timer := time.NewTimer(d.opt.Debounce)
+	timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case change, ok := <-d.watcher.Changes():
+			if !ok {
+				return errors.New("watcher closed")
+			}
+			if calls, ok := d.cookies[change]; ok {
+				// every change made before the cookie was written is pending
+				delete(d.cookies, change)
+				_ = os.Remove(change)
+				d.apply(ctx, pending)
+				pending = map[string]bool{}
+				d.answer(ctx, calls)
+				continue
+			}
+			if !strings.HasPrefix(filepath.Base(change), daemonCookiePrefix) {
+				pending[change] = true
+				timer.Reset(d.opt.Debounce)
+			}
+
+		case <-timer.C:
+			d.apply(ctx, pending)
+			pending = map[string]bool{}
+
+		case cookie := <-d.expired:
+			calls, ok := d.cookies[cookie]
+			if !ok {
+				continue
+			}
+			// the watcher missed the cookie, it may have missed other changes too
+			logger.Warnf("goac daemon: file changes not reported in time, reloading projects\n")
+			delete(d.cookies, cookie)
+			_ = os.Remove(cookie)
+			d.states = map[daemonKey]*daemonState{}
+			pending = map[string]bool{}
+			d.answer(ctx, calls)
+
+		case call := <-d.calls:
+			if call.req.Command == daemonPing || call.req.Command == daemonStop {
+				d.answer(ctx, []*daemonCall{call})
+				if call.req.Command == daemonStop {
+					return nil
+				}
+				continue
+			}
+
+			cookie, err := d.writeCookie()
+			if err != nil {
+				d.apply(ctx, pending)
+				pending = map[string]bool{}
+				d.answer(ctx, []*daemonCall{call})
+				continue
+			}
+			d.cookies[cookie] = append(d.cookies[cookie], call)
+			time.AfterFunc(daemonCookieTimeout, func() {
+				select {
+				case d.expired <- cookie:
+				case <-d.done:
+				}
+			})
+		}
+	}
+}
+
+// writeCookie writes a file in the watched socket directory and returns its path.
+func (d *daemon) writeCookie() (string, error) {
+	d.cookie++
+	cookie := filepath.Join(filepath.Dir(daemonSocket(d.opt.options().rootPath())), fmt.Sprintf("%s%d", daemonCookiePrefix, d.cookie))
+	return cookie, os.WriteFile(cookie, nil, 0o644)
+}
+
+func (d *daemon) answer(ctx context.Context, calls []*daemonCall) {
+	for _, call := range calls {
+		resp, err := d.handle(ctx, call.req)
+		if err != nil {
+			resp = &daemonResponse{Error: err.Error()}
+		}
+
+		// the response is encoded here, the projects are updated by the next changes
+		data, err := json.Marshal(resp)
+		if err != nil {
+			data, _ = json.Marshal(&daemonResponse{Error: err.Error()})
+		}
+		call.reply <- append(data, '\n')
+	}
+}
+
+func (d *daemon) handle(ctx context.Context, req *daemonRequest) (*daemonResponse, error) {
+	if req.Protocol != daemonProtocol {
+		return nil, errors.New("goac daemon of another version")
+	}
+	if req.Command == daemonPing || req.Command == daemonStop {
+		return &daemonResponse{}, nil
+	}
+
+	switch {
+	case req.Scheme != HashSchemeVersion:
+		return nil, errors.New("goac daemon of another version")
+	case req.Dir != d.dir:
+		return nil, fmt.Errorf("goac daemon runs in %s", d.dir)
+	}
+
+	env, err := daemonEnv(d.environ)
+	if err != nil {
+		return nil, err
+	}
+	if !maps.Equal(req.Env, env) {
+		return nil, errors.New("goac daemon runs with another go environment")
+	}
+
+	target := req.Target
+	if req.Command != daemonAffected {
+		target = TargetNone
+	}
+	state, err := d.load(ctx, daemonKey{
+		target:        target,
+		dockerIgnore:  req.DockerIgnore,
+		hashAlgorithm: req.HashAlgorithm,
+		semanticHash:  req.SemanticHash,
+	}, env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch req.Command {
+	case daemonList, daemonAffected:
+		return &daemonResponse{Projects: state.list.Projects}, nil
+	case daemonGraph:
+		return &daemonResponse{Graph: newProjectGraph(state.list.Projects).nodes(state.list.Projects)}, nil
+	}
+	return nil, fmt.Errorf("unknown daemon command %q", req.Command)
+}
+
+// load returns the projects of the key, loaded again if a project was added or removed or if the go env changed.
+func (d *daemon) load(ctx context.Context, key daemonKey, env map[string]string) (*daemonState, error) {
+	configFiles, err := find(d.opt.options().rootPath(), configFileName)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(configFiles)
+
+	if state, ok := d.states[key]; ok && reflect.DeepEqual(state.configFiles, configFiles) && maps.Equal(state.env, env) {
+		return state, nil
+	}
+
+	opt := d.opt.options()
+	opt.Target = key.target
+	opt.DockerIgnore = key.dockerIgnore
+	opt.HashAlgorithm = key.hashAlgorithm
+	opt.SemanticHash = key.semanticHash
+	opt.env = env
+	projects, err := getProjects(ctx, opt)
+	if err != nil {
+		delete(d.states, key)
+		return nil, err
+	}
+
+	state := &daemonState{list: &List{Projects: projects, Options: opt}, configFiles: configFiles, env: env}
+	d.states[key] = state
+	if err := d.watch(); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// apply hashes again the changed files of the loaded projects.
+// The projects of a key are dropped if a config changed or if they can't be loaded, they are loaded on the next request.
+func (d *daemon) apply(ctx context.Context, pending map[string]bool) {
+	files := make([]string, 0, len(pending))
+	for file := range pending {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	files = withoutCacheFiles(files, d.opt.options().cachePath())
+	if len(files) == 0 {
+		return
+	}
+
+	// the created directories are watched before they are hashed, so that none of their files is missed
+	if err := d.watch(); err != nil {
+		logger.Warnf("goac daemon: %s\n", err)
+	}
+
+	configChanged := slices.ContainsFunc(files, func(file string) bool { return filepath.Base(file) == configFileName })
+	for key, state := range d.states {
+		if configChanged {
+			delete(d.states, key)
+			continue
+		}
+		// the watcher reports the files joined to the root, the projects know them relative to the root
+		relFiles, err := state.list.relFiles(files)
+		if err == nil {
+			_, err = state.list.reload(ctx, relFiles)
+		}
+		if err != nil {
+			logger.Warnf("goac daemon: %s\n", err)
+			delete(d.states, key)
+		}
+	}
+
+	if err := d.watch(); err != nil {
+		logger.Warnf("goac daemon: %s\n", err)
+	}
+}
+
+// watch watches the files of the loaded projects, the project directories for their configs,
+// the root directory for go.mod and the socket directory for the cookies.
+func (d *daemon) watch() error {
+	root := d.opt.options().rootPath()
+	dirs := []string{filepath.Clean(root), filepath.Dir(daemonSocket(root)), filepath.Join(root, "vendor")}
+	for _, state := range d.states {
+		dirs = append(dirs, state.list.watchedDirs()...)
+		for _, file := range state.configFiles {
+			dirs = append(dirs, filepath.Dir(file))
+		}
+	}
+	slices.Sort(dirs)
+	return d.watcher.Watch(slices.Compact(dirs))
+}
@@ -0,0 +1,227 @@
+package project
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/kperreau/goac/pkg/hasher"
+)
+
+// DefaultDaemonSocket is the unix socket of the daemon, relative to RootPath.
+var DefaultDaemonSocket = ".goac/daemon.sock"
+
+// daemonProtocol is the version of the daemon requests and responses, a daemon of another version is not used.
+const daemonProtocol = 1
+
+type daemonCommand string
+
+const (
+	daemonPing     daemonCommand = "ping"
+	daemonList     daemonCommand = "list"
+	daemonAffected daemonCommand = "affected"
+	daemonGraph    daemonCommand = "graph"
+	daemonStop     daemonCommand = "stop"
+)
+
+type daemonRequest struct {
+	Protocol      int
+	Scheme        int
+	Command       daemonCommand
+	Dir           string
+	Env           map[string]string
+	Target        Target
+	DockerIgnore  bool
+	HashAlgorithm hasher.Algorithm
+	SemanticHash  bool
+}
+
+type daemonResponse struct {
+	Projects []*Project   `json:",omitempty"`
+	Graph    []*GraphNode `json:",omitempty"`
+	Error    string       `json:",omitempty"`
+}
+
+// errDaemonNotRunning is returned when no daemon listens on the socket.
+var errDaemonNotRunning = errors.New("goac daemon is not running")
+
+func daemonSocket(root string) string {
+	if filepath.IsAbs(DefaultDaemonSocket) {
+		return DefaultDaemonSocket
+	}
+	return filepath.Join(root, DefaultDaemonSocket)
+}
+
+// daemonEnvKeys are the go env variables changing the packages listed by go list or the hashes,
+// the daemon is only used by commands run with the same values.
+var daemonEnvKeys = append([]string{"GOFLAGS", "GOOS", "GOARCH", "GOWORK", "GOEXPERIMENT"}, cgoEnvKeys...)
+
+// daemonEnv returns the go env values of daemonEnvKeys, set in environ or with go env -w.
+func daemonEnv(environ []string) (map[string]string, error) {
+	return readGoEnv(environ, daemonEnvKeys...)
+}
+
+func newDaemonRequest(command daemonCommand, opt *Options) (*daemonRequest, error) {
+	dir, err := filepath.Abs(opt.rootPath())
+	if err != nil {
+		return nil, err
+	}
+	env, err := daemonEnv(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &daemonRequest{
+		Protocol:      daemonProtocol,
+		Scheme:        HashSchemeVersion,
+		Command:       command,
+		Dir:           dir,
+		Env:           env,
+		Target:        opt.Target,
+		DockerIgnore:  opt.DockerIgnore,
+		HashAlgorithm: opt.hashAlgorithm(),
+		SemanticHash:  opt.SemanticHash,
+	}, nil
+}
+
+// callDaemon sends a request to the daemon listening on socket and returns its response.
+func callDaemon(ctx context.Context, socket string, req *daemonRequest) (*daemonResponse, error) {
+	dialer := net.Dialer{Timeout: time.Second}
+	conn, err := dialer.DialContext(ctx, "unix", socket)
+	if err != nil {
+		return nil, errDaemonNotRunning
+	}
+	defer conn.Close()
+
+	// loading the projects may be long, the request is only bounded by ctx
+	stop := context.AfterFunc(ctx, func() { _ = conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, err
+	}
+
+	var resp daemonResponse
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return nil, err
+	}
+	if resp.Error != "" {
+		return nil, errors.New(resp.Error)
+	}
+
+	return &resp, nil
+}
+
+// requestDaemon sends the command to the daemon with the options, it reports false if no daemon runs
+// or if it can't answer for these options. Debug output is only printed without daemon.
+func requestDaemon(ctx context.Context, opt *Options, command daemonCommand) (*daemonResponse, bool) {
+	if opt.NoDaemon || len(opt.Debug) > 0 || opt.MaxConcurrency < 1 {
+		return nil, false
+	}
+	if _, err := os.Stat(daemonSocket(opt.rootPath())); err != nil {
+		return nil, false
+	}
+
+	req, err := newDaemonRequest(command, opt)
+	if err != nil {
+		return nil, false
+	}
+
+	span := opt.tracer().Start("goac", "daemon "+string(command))
+	resp, err := callDaemon(ctx, daemonSocket(opt.rootPath()), req)
+	span.End()
+	if err != nil {
+		if !errors.Is(err, errDaemonNotRunning) && ctx.Err() == nil {
+			opt.logger().Warnf("goac daemon not used: %s\n", err)
+		}
+		return nil, false
+	}
+
+	return resp, true
+}
+
+// loadFromDaemon returns the selected projects loaded by the daemon with their hashes, and loads their cache.
+// It reports false if the daemon is not used.
+func loadFromDaemon(ctx context.Context, opt *Options) ([]*Project, bool, error) {
+	command := daemonAffected
+	if opt.Target == TargetNone {
+		command = daemonList
+	}
+
+	resp, ok := requestDaemon(ctx, opt, command)
+	if !ok {
+		return nil, false, nil
+	}
+
+	hashPool := hasher.NewAlgorithmPool(opt.hashAlgorithm())
+	for _, p := range resp.Projects {
+		p.CMDOptions = opt
+		p.HashPool = hashPool
+	}
+
+	projects, err := selectLoaded(opt, resp.Projects)
+	if err != nil {
+		return nil, true, err
+	}
+
+	// like the local loading, only the selected projects are reported
+	for _, p := range projects {
+		p.emit(Event{Type: EventProjectLoaded})
+		if opt.Target != TargetNone {
+			p.emit(Event{Type: EventHashComputed})
+		}
+	}
+	return projects, true, nil
+}
+
+// selectLoaded selects projects among all the loaded projects, and loads their cache.
+func selectLoaded(opt *Options, all []*Project) ([]*Project, error) {
+	selector := opt.selector()
+	if err := selector.Validate(); err != nil {
+		return nil, err
+	}
+
+	found := &selectables{}
+	var selected []*Project
+	for _, p := range all {
+		found.add(p)
+		if selector.Match(p.Name, p.CleanPath, p.Tags) {
+			selected = append(selected, p)
+		}
+	}
+	selector.warnUnmatched(opt.logger(), found.projects)
+
+	projects := selected
+	if opt.expandSelection() {
+		projects = newProjectGraph(all).expand(all, selected, opt.WithDependents, opt.WithDependencies)
+	}
+
+	if opt.Target != TargetNone {
+		for _, p := range projects {
+			span := p.span("load cache")
+			err := p.LoadCache()
+			span.End()
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return projects, nil
+}
+
+// PingDaemon returns an error if no daemon answers on the daemon socket of the repository root.
+func PingDaemon(ctx context.Context, root string) error {
+	_, err := callDaemon(ctx, daemonSocket(root), &daemonRequest{Protocol: daemonProtocol, Command: daemonPing})
+	return err
+}
+
+// StopDaemon stops the daemon answering on the daemon socket of the repository root.
+func StopDaemon(ctx context.Context, root string) error {
+	_, err := callDaemon(ctx, daemonSocket(root), &daemonRequest{Protocol: daemonProtocol, Command: daemonStop})
+	return err
+}
@@ -0,0 +1,192 @@
+package project
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kperreau/goac/pkg/logger"
+	"github.com/kperreau/goac/pkg/watch"
+	"github.com/stretchr/testify/assert"
+)
+
+// startDaemon runs a daemon in a new go module with two projects, and returns the root of the module.
+func startDaemon(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":                   "module example.com/ws\n\ngo 1.22\n",
+		"api/main.go":              "package main\n\nfunc main() {}\n",
+		"api/" + configFileName:    "version: 1.0\nname: api\ntarget:\n  build:\n    exec:\n      cmd: \"true\"\n",
+		"worker/main.go":           "package main\n\nimport _ \"example.com/ws/api/lib\"\n\nfunc main() {}\n",
+		"worker/" + configFileName: "version: 1.0\nname: worker\ntarget:\n  build:\n    exec:\n      cmd: \"true\"\n",
+		"api/lib/lib.go":           "package lib\n",
+	}
+	for file, content := range files {
+		assert.NoError(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(file)), 0o755))
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, file), []byte(content), 0o644))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		_, err := redirectAffectedStdout(func() error {
+			return RunDaemon(ctx, watch.New(10*time.Millisecond), &DaemonOptions{MaxConcurrency: 2, Debounce: 10 * time.Millisecond, RootPath: dir})
+		})
+		done <- err
+	}()
+
+	t.Cleanup(func() {
+		cancel()
+		assert.NoError(t, <-done)
+	})
+
+	assert.Eventually(t, func() bool { return PingDaemon(context.Background(), dir) == nil }, 5*time.Second, 10*time.Millisecond)
+	return dir
+}
+
+func loadProject(t *testing.T, opt *Options, name string) *Project {
+	t.Helper()
+
+	l, err := NewProjectsList(context.Background(), opt)
+	assert.NoError(t, err)
+	for _, p := range l.(*List).Projects {
+		if p.Name == name {
+			return p
+		}
+	}
+	t.Fatalf("project %s not found", name)
+	return nil
+}
+
+func TestDaemon_ServesHashesUpToDate(t *testing.T) {
+	// Arrange
+	root := startDaemon(t)
+	opt := &Options{Target: TargetBuild, MaxConcurrency: 2, RootPath: root}
+	first := loadProject(t, opt, "api")
+
+	// Act
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "api", "main.go"), []byte("package main\n\nfunc main() { println() }\n"), 0o644))
+	second := loadProject(t, opt, "api")
+	local := loadProject(t, &Options{Target: TargetBuild, MaxConcurrency: 2, RootPath: root, NoDaemon: true}, "api")
+
+	// Assert
+	assert.Nil(t, first.HashMemo, "loaded by the daemon")
+	assert.NotNil(t, first.Cache)
+	assert.NotEqual(t, first.Metadata.DirHash, second.Metadata.DirHash)
+	assert.Equal(t, local.Metadata, second.Metadata)
+}
+
+func TestDaemon_SendsEventsOfSelectedProjects(t *testing.T) {
+	// Arrange
+	root := startDaemon(t)
+	events := &eventRecorder{}
+	opt := &Options{Target: TargetBuild, MaxConcurrency: 2, RootPath: root, ProjectsName: []string{"api"}, Subscribers: []Subscriber{events}}
+
+	// Act
+	p := loadProject(t, opt, "api")
+
+	// Assert
+	assert.Nil(t, p.HashMemo, "loaded by the daemon")
+	assert.Equal(t, []EventType{EventProjectLoaded, EventHashComputed}, events.types("api"))
+	assert.Empty(t, events.types("worker"))
+}
+
+func TestDaemon_ServesGraph(t *testing.T) {
+	root := startDaemon(t)
+
+	nodes, err := LoadGraph(context.Background(), &Options{MaxConcurrency: 2, RootPath: root})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []*GraphNode{
+		{Name: "api", Path: "api", Dependents: []string{"worker"}},
+		{Name: "worker", Path: "worker", Dependencies: []string{"api"}},
+	}, nodes)
+}
+
+func TestDaemon_NotUsedWithAnotherEnvironment(t *testing.T) {
+	// Arrange
+	root := startDaemon(t)
+	t.Setenv("CGO_CFLAGS", "-O3")
+
+	var stdout, stderr bytes.Buffer
+	opt := &Options{Target: TargetNone, MaxConcurrency: 2, RootPath: root, Logger: newTestLogger(logger.LevelInfo, &stdout, &stderr)}
+
+	// Act
+	p := loadProject(t, opt, "api")
+
+	// Assert
+	assert.NotNil(t, p.HashMemo, "loaded without the daemon")
+	assert.Empty(t, stdout.String())
+	assert.Contains(t, stderr.String(), "goac daemon not used: goac daemon runs with another go environment")
+}
+
+func TestDaemon_ReadsGoEnvOnEachRequest(t *testing.T) {
+	// Arrange
+	goenv := filepath.Join(t.TempDir(), "go.env")
+	t.Setenv("GOENV", goenv)
+	t.Setenv("CGO_ENABLED", "1")
+	root := startDaemon(t)
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "api", "cgo.go"), []byte("package main\n\nimport \"C\"\n"), 0o644))
+	opt := &Options{Target: TargetBuild, MaxConcurrency: 2, RootPath: root}
+	first := loadProject(t, opt, "api")
+
+	// Act
+	assert.NoError(t, os.WriteFile(goenv, []byte("CGO_CFLAGS=-O3\n"), 0o644))
+	second := loadProject(t, opt, "api")
+
+	// Assert
+	assert.Nil(t, second.HashMemo, "loaded by the daemon")
+	assert.NotEqual(t, first.Metadata.DependenciesHash, second.Metadata.DependenciesHash)
+}
+
+func TestDaemon_AddedProjectIsLoaded(t *testing.T) {
+	// Arrange
+	root := startDaemon(t)
+	opt := &Options{Target: TargetNone, MaxConcurrency: 2, RootPath: root}
+	loadProject(t, opt, "api")
+
+	// Act
+	dir := filepath.Join(root, "batch")
+	assert.NoError(t, os.MkdirAll(dir, 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, configFileName), []byte("version: 1.0\nname: batch\n"), 0o644))
+
+	// Assert
+	assert.Equal(t, "batch", loadProject(t, opt, "batch").Name)
+}
+
+func TestDaemon_AlreadyRunning(t *testing.T) {
+	root := startDaemon(t)
+
+	err := RunDaemon(context.Background(), watch.New(time.Second), &DaemonOptions{MaxConcurrency: 1, RootPath: root})
+
+	assert.ErrorContains(t, err, "goac daemon already running")
+}
+
+func TestDaemon_Stop(t *testing.T) {
+	// Arrange
+	root := t.TempDir()
+
+	done := make(chan error)
+	go func() {
+		_, err := redirectAffectedStdout(func() error {
+			return RunDaemon(context.Background(), watch.New(time.Second), &DaemonOptions{MaxConcurrency: 1, RootPath: root})
+		})
+		done <- err
+	}()
+	assert.Eventually(t, func() bool { return PingDaemon(context.Background(), root) == nil }, 5*time.Second, 10*time.Millisecond)
+
+	// Act
+	err := StopDaemon(context.Background(), root)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NoError(t, <-done)
+	assert.NoFileExists(t, daemonSocket(root))
+	assert.Error(t, PingDaemon(context.Background(), root))
+}
@@ -10,7 +10,7 @@ import (
 	"strings"
 
 	"github.com/fatih/color"
-	"github.com/kperreau/goac/pkg/printer"
+	"github.com/kperreau/goac/pkg/logger"
 
 	"gopkg.in/yaml.v3"
 )
@@ -28,20 +28,20 @@ func Discover(opts *DiscoverOptions) error {
 		return fmt.Errorf("failed to discover projects: %w", err)
 	}
 
-	printer.Printf("Discovered %s potential projects\n", color.YellowString("%d", len(filesPath)))
+	logger.Printf("Discovered %s potential projects\n", color.YellowString("%d", len(filesPath)))
 	for _, filePath := range filesPath {
 		path := filepath.Clean(strings.Replace(filePath, "main.go", "", 1))
 		name := pathToName(path)
 		if !opts.Create {
-			printer.Printf("%s %s %s\n", color.BlueString(name), color.YellowString("=>"), path)
+			logger.Printf("%s %s %s\n", color.BlueString(name), color.YellowString("=>"), path)
 		} else {
 			statusResult, err := createConfigFile(filepath.Join(path, configFileName), name, opts.Force)
 			if err != nil {
-				printer.Printf("Failed to create project %s %s %s | Error: %s\n", color.BlueString(name), color.YellowString("=>"), path, color.RedString(err.Error()))
+				logger.Errorf("Failed to create project %s %s %s | Error: %s\n", color.BlueString(name), color.YellowString("=>"), path, color.RedString(err.Error()))
 				continue
 			}
 
-			printer.Printf("%s %s %s [%s]\n", color.BlueString(name), color.YellowString("=>"), path, printDiscoverStatus(statusResult))
+			logger.Printf("%s %s %s [%s]\n", color.BlueString(name), color.YellowString("=>"), path, printDiscoverStatus(statusResult))
 		}
 	}
 
@@ -62,7 +62,7 @@ func searchProjects() ([]string, error) {
 			// Parse go file
 			f, parseErr := parser.ParseFile(fset, path, nil, 0)
 			if parseErr != nil {
-				fmt.Println(parseErr)
+				logger.Warnf("Skipping a file that does not parse: %s\n", parseErr)
 				return nil
 			}
 
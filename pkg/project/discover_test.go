@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/fatih/color"
+	"github.com/kperreau/goac/pkg/logger"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -157,6 +158,32 @@ func TestSearchProjects_ReturnsListOfDirectories(t *testing.T) {
 	assert.Equal(t, expected, result)
 }
 
+func TestSearchProjects_WarnsAboutFilesThatDoNotParse(t *testing.T) {
+	// Arrange
+	tmp := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(tmp, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tmp, "broken.go"), []byte("not go"), 0o644))
+
+	// update default path to search in
+	OldPahToSearch := PahToSearch
+	PahToSearch = tmp
+	defer func() { PahToSearch = OldPahToSearch }()
+
+	var stdout, stderr bytes.Buffer
+	oldLogger := logger.Default()
+	logger.SetDefault(logger.New(logger.Options{Level: logger.LevelInfo, Stdout: &stdout, Stderr: &stderr}))
+	defer logger.SetDefault(oldLogger)
+
+	// Act
+	result, err := searchProjects()
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(tmp, "main.go")}, result)
+	assert.Empty(t, stdout.String())
+	assert.Contains(t, stderr.String(), "Skipping a file that does not parse: "+filepath.Join(tmp, "broken.go"))
+}
+
 func TestSearchProjects_ReturnsErrorForInvalidPath(t *testing.T) {
 	// Arrange
 	// update default path to search in
@@ -0,0 +1,148 @@
+package project
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ProjectError is an error of a project with its context, Fields returns it for structured output.
+type ProjectError interface {
+	error
+	Fields() map[string]string
+}
+
+// ConfigError is the failure to read or parse the config file of a project.
+type ConfigError struct {
+	Path string
+	Err  error
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("error loading config %s: %s", e.Path, e.Err)
+}
+
+func (e *ConfigError) Unwrap() error { return e.Err }
+
+func (e *ConfigError) Fields() map[string]string {
+	return map[string]string{"kind": "config", "path": e.Path}
+}
+
+// ModuleError is the failure to resolve the packages of a project, with the go command and its stderr.
+type ModuleError struct {
+	Project string
+	Path    string
+	Command string
+	Stderr  string
+	Err     error
+}
+
+func (e *ModuleError) Error() string {
+	msg := fmt.Sprintf("error loading modules of %s: %s", e.Project, e.Err)
+	if e.Command != "" {
+		msg = fmt.Sprintf("error loading modules of %s: %s: %s", e.Project, e.Command, e.Err)
+	}
+	if stderr := strings.TrimSpace(e.Stderr); stderr != "" {
+		msg += ": " + stderr
+	}
+	return msg
+}
+
+func (e *ModuleError) Unwrap() error { return e.Err }
+
+func (e *ModuleError) Fields() map[string]string {
+	return withOptionalFields(map[string]string{"kind": "module", "project": e.Project, "path": e.Path},
+		"command", e.Command, "stderr", e.Stderr)
+}
+
+// HashError is the failure to hash the files or the dependencies of a project.
+type HashError struct {
+	Project string
+	Path    string
+	Err     error
+}
+
+func (e *HashError) Error() string {
+	return fmt.Sprintf("error hashing %s: %s", e.Project, e.Err)
+}
+
+func (e *HashError) Unwrap() error { return e.Err }
+
+func (e *HashError) Fields() map[string]string {
+	return map[string]string{"kind": "hash", "project": e.Project, "path": e.Path}
+}
+
+func (e *BuildError) Fields() map[string]string {
+	return withOptionalFields(map[string]string{"kind": "target", "project": e.Project, "path": e.Path},
+		"command", e.Command, "stderr", e.Stderr)
+}
+
+// withOptionalFields adds the key value pairs with a value to fields.
+func withOptionalFields(fields map[string]string, keyValues ...string) map[string]string {
+	for i := 0; i+1 < len(keyValues); i += 2 {
+		if keyValues[i+1] != "" {
+			fields[keyValues[i]] = keyValues[i+1]
+		}
+	}
+	return fields
+}
+
+// LoadErrors are the errors of all the projects that failed to load, sorted by project path.
+type LoadErrors []error
+
+func newLoadErrors(errs []error) LoadErrors {
+	sort.SliceStable(errs, func(i, j int) bool { return errorPath(errs[i]) < errorPath(errs[j]) })
+	return errs
+}
+
+func (e LoadErrors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+
+	msgs := make([]string, 0, len(e)+1)
+	msgs = append(msgs, fmt.Sprintf("%d projects failed to load:", len(e)))
+	for _, err := range e {
+		msgs = append(msgs, "  "+err.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+func (e LoadErrors) Unwrap() []error { return e }
+
+// errorPath returns the path of the project of an error, empty if it has none.
+func errorPath(err error) string {
+	var pe ProjectError
+	if errors.As(err, &pe) {
+		return pe.Fields()["path"]
+	}
+	return ""
+}
+
+// fieldsLogger is implemented by the loggers adding fields to structured output, like *logger.Logger.
+type fieldsLogger interface {
+	ErrorWith(fields map[string]string, format string, a ...any)
+}
+
+// LogError prints an error, each error of LoadErrors on its own. The fields of project errors are
+// added to the output of loggers supporting them.
+func LogError(log Logger, err error) {
+	var loadErrs LoadErrors
+	if errors.As(err, &loadErrs) {
+		for _, err := range loadErrs {
+			logProjectError(log, err, "%s\n", err)
+		}
+		return
+	}
+	logProjectError(log, err, "%s\n", err)
+}
+
+func logProjectError(log Logger, err error, format string, a ...any) {
+	var pe ProjectError
+	if fl, ok := log.(fieldsLogger); ok && errors.As(err, &pe) {
+		fl.ErrorWith(pe.Fields(), format, a...)
+		return
+	}
+	log.Errorf(format, a...)
+}
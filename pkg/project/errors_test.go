@@ -0,0 +1,108 @@
+package project
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kperreau/goac/pkg/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadGOModules_ModuleErrorWithStderr(t *testing.T) {
+	// Arrange
+	root := newWorkspaceRepo(t)
+	p := &Project{Name: "missing", Path: "./missing", CleanPath: "missing", CMDOptions: &Options{RootPath: root}}
+
+	// Act
+	err := p.LoadGOModules(context.Background(), nil)
+
+	// Assert
+	var moduleErr *ModuleError
+	assert.ErrorAs(t, err, &moduleErr)
+	assert.Equal(t, "missing", moduleErr.Project)
+	assert.Contains(t, moduleErr.Command, "go list -deps -json ./missing")
+	assert.NotEmpty(t, moduleErr.Stderr)
+	assert.Contains(t, err.Error(), moduleErr.Stderr[:10])
+}
+
+func TestGetProjects_ReportsEveryLoadingError(t *testing.T) {
+	// Arrange
+	root := newWorkspaceRepo(t)
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "api", configFileName), []byte("name: [\n"), 0o644))
+	assert.NoError(t, os.MkdirAll(filepath.Join(root, "broken"), 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "broken", configFileName), []byte("version: 1.0\nname: broken\n"), 0o644))
+
+	// Act
+	_, err := getProjects(context.Background(), &Options{Target: TargetNone, MaxConcurrency: 2, RootPath: root, Logger: logger.Discard()})
+
+	// Assert
+	var loadErrs LoadErrors
+	assert.ErrorAs(t, err, &loadErrs)
+	assert.Len(t, loadErrs, 2)
+	var configErr *ConfigError
+	assert.ErrorAs(t, loadErrs[0], &configErr)
+	assert.Equal(t, filepath.Join("api", configFileName), configErr.Path)
+	var moduleErr *ModuleError
+	assert.ErrorAs(t, loadErrs[1], &moduleErr)
+	assert.Equal(t, "broken", moduleErr.Project)
+	assert.Contains(t, err.Error(), "2 projects failed to load:")
+}
+
+func TestLoadErrors_SingleErrorMessage(t *testing.T) {
+	err := newLoadErrors([]error{&HashError{Project: "api", Path: "api", Err: errors.New("permission denied")}})
+
+	assert.Equal(t, "error hashing api: permission denied", err.Error())
+}
+
+func TestBuildError_Message(t *testing.T) {
+	cases := []struct {
+		err      *BuildError
+		expected string
+	}{
+		{
+			&BuildError{Project: "api", Command: "make build", Err: errors.New("exit status 2"), Stderr: "no rule\n"},
+			"error building api: make build: exit status 2: no rule",
+		},
+		{
+			&BuildError{Project: "api", Command: "make build", Err: errors.New("exit status 2")},
+			"error building api: make build: exit status 2",
+		},
+		{
+			&BuildError{Project: "api", Err: errors.New("exit status 2"), Stderr: "  \n"},
+			"error building api: exit status 2",
+		},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.expected, c.err.Error())
+	}
+}
+
+func TestLogError_AddsFieldsOfEachError(t *testing.T) {
+	// Arrange
+	var stderr bytes.Buffer
+	log := logger.New(logger.Options{Level: logger.LevelInfo, Format: logger.FormatJSON, Stderr: &stderr})
+	err := newLoadErrors([]error{
+		&BuildError{Project: "worker", Path: "worker", Command: "make", Err: errors.New("exit status 2"), Stderr: "no rule"},
+		&ConfigError{Path: "api/" + configFileName, Err: errors.New("bad yaml")},
+	})
+
+	// Act
+	LogError(log, err)
+
+	// Assert
+	decoder := json.NewDecoder(&stderr)
+	var first, second map[string]string
+	assert.NoError(t, decoder.Decode(&first))
+	assert.NoError(t, decoder.Decode(&second))
+	assert.Equal(t, "config", first["kind"])
+	assert.Equal(t, "target", second["kind"])
+	assert.Equal(t, "make", second["command"])
+	assert.Equal(t, "no rule", second["stderr"])
+	assert.Equal(t, "worker", second["project"])
+}
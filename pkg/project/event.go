@@ -0,0 +1,127 @@
+package project
+
+import (
+	"bytes"
+	"sync"
+	"time"
+)
+
+// EventType is a step of the lifecycle of a project in a run.
+type EventType string
+
+const (
+	// EventProjectLoaded is sent once the config and the packages of a project are loaded.
+	EventProjectLoaded EventType = "project-loaded"
+	// EventHashComputed is sent once the hashes of a project are computed for the target.
+	EventHashComputed EventType = "hash-computed"
+	// EventCacheHit and EventCacheMiss are sent before building the affected projects, the reason of a miss is set.
+	EventCacheHit  EventType = "cache-hit"
+	EventCacheMiss EventType = "cache-miss"
+	// EventTargetStarted is sent before running the target command of a project.
+	EventTargetStarted EventType = "target-started"
+	// EventOutputLine is sent for each line printed by the target command, on the stream it was printed on.
+	EventOutputLine EventType = "output-line"
+	// EventTargetFinished and EventTargetFailed are sent once the target command ends, with its duration.
+	EventTargetFinished EventType = "target-finished"
+	EventTargetFailed   EventType = "target-failed"
+	// EventCacheWritten is sent once the cache entry of a project is written.
+	EventCacheWritten EventType = "cache-written"
+)
+
+// Output streams of EventOutputLine.
+const (
+	StreamStdout = "stdout"
+	StreamStderr = "stderr"
+)
+
+// Event is a step of the lifecycle of a project, only the fields of its type are set.
+type Event struct {
+	Type    EventType
+	Time    time.Time
+	Project string
+	Path    string
+	Target  Target
+	// Hash is the content hash of the project, set once computed.
+	Hash string
+	// Reason explains a cache miss.
+	Reason string
+	// Stream and Line are the output line of the target command.
+	Stream string
+	Line   string
+	// Duration is the duration of the target command, Err its error on failure.
+	Duration time.Duration
+	Err      error
+}
+
+// Subscriber receives the events of a run. Events of several projects are sent concurrently,
+// the events of a project are sent in order.
+type Subscriber interface {
+	OnEvent(e Event)
+}
+
+// SubscriberFunc is a function receiving the events of a run.
+type SubscriberFunc func(e Event)
+
+func (f SubscriberFunc) OnEvent(e Event) { f(e) }
+
+func (o *Options) hasSubscribers() bool {
+	return o != nil && len(o.Subscribers) > 0
+}
+
+// emit sends an event of the project to the subscribers of the run.
+func (p *Project) emit(e Event) {
+	if !p.CMDOptions.hasSubscribers() {
+		return
+	}
+
+	e.Time = time.Now()
+	e.Project, e.Path, e.Target = p.Name, p.CleanPath, p.CMDOptions.Target
+	if e.Hash == "" && p.Metadata != nil && e.Type != EventProjectLoaded {
+		e.Hash = p.Metadata.ContentHash
+	}
+	for _, s := range p.CMDOptions.Subscribers {
+		s.OnEvent(e)
+	}
+}
+
+// outputWriter keeps the output of the target command and sends each of its lines to the subscribers.
+// The writers of the streams of a command share mu, so that their lines are sent one at a time.
+type outputWriter struct {
+	p      *Project
+	stream string
+	mu     *sync.Mutex
+	output bytes.Buffer
+	line   []byte
+}
+
+func (w *outputWriter) Write(b []byte) (int, error) {
+	w.output.Write(b)
+	if !w.p.CMDOptions.hasSubscribers() {
+		return len(b), nil
+	}
+
+	w.line = append(w.line, b...)
+	for {
+		i := bytes.IndexByte(w.line, '\n')
+		if i < 0 {
+			break
+		}
+		w.send(string(w.line[:i]))
+		w.line = w.line[i+1:]
+	}
+	return len(b), nil
+}
+
+// flush sends the last line when the output does not end with a new line.
+func (w *outputWriter) flush() {
+	if len(w.line) > 0 {
+		w.send(string(w.line))
+		w.line = nil
+	}
+}
+
+func (w *outputWriter) send(line string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.p.emit(Event{Type: EventOutputLine, Stream: w.stream, Line: line})
+}
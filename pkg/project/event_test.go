@@ -0,0 +1,96 @@
+package project
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// eventRecorder records the events of a run by project.
+type eventRecorder struct {
+	mu     sync.Mutex
+	events map[string][]Event
+}
+
+func (r *eventRecorder) OnEvent(e Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.events == nil {
+		r.events = map[string][]Event{}
+	}
+	r.events[e.Project] = append(r.events[e.Project], e)
+}
+
+func (r *eventRecorder) types(project string) (types []EventType) {
+	for _, e := range r.events[project] {
+		types = append(types, e.Type)
+	}
+	return types
+}
+
+func TestWorkspaceBuild_SendsLifecycleEvents(t *testing.T) {
+	// Arrange
+	w := NewWorkspace(WorkspaceOptions{Root: newWorkspaceRepo(t), CachePath: t.TempDir()})
+	first, second := &eventRecorder{}, &eventRecorder{}
+
+	// Act
+	_, err := w.Build(context.Background(), Options{Target: TargetBuild, NoDaemon: true, Subscribers: []Subscriber{first}})
+	assert.NoError(t, err)
+	_, err = w.Build(context.Background(), Options{Target: TargetBuild, NoDaemon: true, Subscribers: []Subscriber{second}})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, []EventType{
+		EventProjectLoaded, EventHashComputed, EventCacheMiss, EventTargetStarted, EventTargetFinished, EventCacheWritten,
+	}, first.types("api"))
+	assert.Equal(t, "not built yet", first.events["api"][2].Reason)
+	assert.Equal(t, "api", first.events["api"][0].Path)
+	assert.NotEmpty(t, first.events["api"][5].Hash)
+	assert.Equal(t, []EventType{EventProjectLoaded, EventHashComputed, EventCacheHit}, second.types("api"))
+}
+
+func TestExec_SendsOutputLines(t *testing.T) {
+	// Arrange
+	var lines []string
+	p := &Project{
+		Name: "test-project",
+		Target: map[Target]*TargetConfig{
+			TargetBuild: {Exec: &Exec{CMD: "sh", Params: []string{"-c", "echo first; echo second >&2; printf third"}}},
+		},
+		CMDOptions: &Options{
+			Target: TargetBuild,
+			Subscribers: []Subscriber{SubscriberFunc(func(e Event) {
+				lines = append(lines, e.Stream+": "+e.Line)
+			})},
+		},
+	}
+
+	// Act
+	err := p.exec(context.Background(), 0)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"stdout: first", "stderr: second", "stdout: third"}, lines)
+}
+
+func TestExec_FailedCommandKeepsOutput(t *testing.T) {
+	// Arrange
+	p := &Project{
+		Name: "test-project",
+		Target: map[Target]*TargetConfig{
+			TargetBuild: {Exec: &Exec{CMD: "sh", Params: []string{"-c", "echo out; echo err >&2; exit 1"}}},
+		},
+		CMDOptions: &Options{Target: TargetBuild},
+	}
+
+	// Act
+	err := p.exec(context.Background(), 0)
+
+	// Assert
+	var buildErr *BuildError
+	assert.ErrorAs(t, err, &buildErr)
+	assert.Equal(t, "out\n", buildErr.Stdout)
+	assert.Equal(t, "err\n", buildErr.Stderr)
+}
@@ -0,0 +1,138 @@
+package project
+
+import (
+	"context"
+	"path"
+	"slices"
+	"strings"
+)
+
+// projectGraph links the projects through the packages they compile: a project depends on
+// the projects owning the local packages it imports. Packages are listed with go list -deps,
+// so the dependencies of a project are already transitive.
+type projectGraph struct {
+	dependencies map[*Project][]*Project
+	dependents   map[*Project][]*Project
+}
+
+func newProjectGraph(projects []*Project) *projectGraph {
+	g := &projectGraph{
+		dependencies: map[*Project][]*Project{},
+		dependents:   map[*Project][]*Project{},
+	}
+
+	for _, p := range projects {
+		if p.Module == nil {
+			continue
+		}
+		for _, pkg := range p.Module.Packages {
+			owner := packageOwner(projects, pkg.Dir)
+			if owner == nil || owner == p || slices.Contains(g.dependencies[p], owner) {
+				continue
+			}
+			g.dependencies[p] = append(g.dependencies[p], owner)
+			g.dependents[owner] = append(g.dependents[owner], p)
+		}
+	}
+
+	return g
+}
+
+// packageOwner returns the project of a package directory, the project with the longest path
+// containing it. Vendored packages have no owner.
+func packageOwner(projects []*Project, dir string) (owner *Project) {
+	dir = path.Clean(dir)
+	if dir == "vendor" || strings.HasPrefix(dir, "vendor/") {
+		return nil
+	}
+
+	for _, p := range projects {
+		projectPath := path.Clean(p.CleanPath)
+		if projectPath != "." && dir != projectPath && !strings.HasPrefix(dir, projectPath+"/") {
+			continue
+		}
+		if owner == nil || len(projectPath) > len(path.Clean(owner.CleanPath)) {
+			owner = p
+		}
+	}
+
+	return owner
+}
+
+// expand returns the selected projects with their dependents and/or dependencies, in the order of all.
+func (g *projectGraph) expand(all []*Project, selected []*Project, withDependents bool, withDependencies bool) []*Project {
+	keep := map[*Project]bool{}
+	for _, p := range selected {
+		keep[p] = true
+		if withDependents {
+			for _, dependent := range g.dependents[p] {
+				keep[dependent] = true
+			}
+		}
+		if withDependencies {
+			for _, dependency := range g.dependencies[p] {
+				keep[dependency] = true
+			}
+		}
+	}
+
+	expanded := make([]*Project, 0, len(keep))
+	for _, p := range all {
+		if keep[p] {
+			expanded = append(expanded, p)
+		}
+	}
+	return expanded
+}
+
+// GraphNode is a project with the projects it depends on and the projects depending on it, by name.
+type GraphNode struct {
+	Name         string   `json:"name"`
+	Path         string   `json:"path"`
+	Tags         []string `json:"tags,omitempty"`
+	Dependencies []string `json:"dependencies,omitempty"`
+	Dependents   []string `json:"dependents,omitempty"`
+}
+
+// nodes returns the nodes of the projects, sorted by path.
+func (g *projectGraph) nodes(projects []*Project) []*GraphNode {
+	names := func(projects []*Project) (names []string) {
+		for _, p := range projects {
+			names = append(names, p.Name)
+		}
+		slices.Sort(names)
+		return names
+	}
+
+	nodes := make([]*GraphNode, 0, len(projects))
+	for _, p := range projects {
+		nodes = append(nodes, &GraphNode{
+			Name:         p.Name,
+			Path:         p.CleanPath,
+			Tags:         p.Tags,
+			Dependencies: names(g.dependencies[p]),
+			Dependents:   names(g.dependents[p]),
+		})
+	}
+	slices.SortFunc(nodes, func(a, b *GraphNode) int { return strings.Compare(a.Path, b.Path) })
+	return nodes
+}
+
+// LoadGraph returns the dependencies between all the projects, from the daemon when it runs.
+func LoadGraph(ctx context.Context, opt *Options) ([]*GraphNode, error) {
+	if resp, ok := requestDaemon(ctx, opt, daemonGraph); ok {
+		return resp.Graph, nil
+	}
+
+	projects, err := getProjects(ctx, &Options{
+		Target:         TargetNone,
+		MaxConcurrency: opt.MaxConcurrency,
+		Tracer:         opt.Tracer,
+		RootPath:       opt.RootPath,
+		Logger:         opt.Logger,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newProjectGraph(projects).nodes(projects), nil
+}
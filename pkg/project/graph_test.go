@@ -0,0 +1,104 @@
+package project
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newGraphProject(name string, cleanPath string, dirs ...string) *Project {
+	p := &Project{Name: name, CleanPath: cleanPath, Module: &Module{}}
+	for _, dir := range dirs {
+		p.Module.Packages = append(p.Module.Packages, &Package{Dir: dir})
+	}
+	return p
+}
+
+func newGraphProjects() (lib *Project, api *Project, worker *Project, tool *Project) {
+	lib = newGraphProject("lib", "libs/shared", "libs/shared", "libs/shared/internal")
+	api = newGraphProject("api", "services/api", "services/api", "libs/shared", "libs/shared/internal")
+	worker = newGraphProject("worker", "services/worker", "services/worker", "services/api", "libs/shared", "vendor/example.com/x")
+	tool = newGraphProject("tool", "tools/tool", "tools/tool")
+	return lib, api, worker, tool
+}
+
+func TestPackageOwner(t *testing.T) {
+	root := newGraphProject("root", ".")
+	lib := newGraphProject("lib", "libs/shared")
+	projects := []*Project{root, lib}
+
+	assert.Equal(t, lib, packageOwner(projects, "libs/shared"))
+	assert.Equal(t, lib, packageOwner(projects, "libs/shared/internal"))
+	assert.Equal(t, root, packageOwner(projects, "libs/sharedtoo"))
+	assert.Equal(t, root, packageOwner(projects, "pkg/utils"))
+	assert.Nil(t, packageOwner(projects, "vendor/example.com/x"))
+	assert.Nil(t, packageOwner([]*Project{lib}, "pkg/utils"))
+}
+
+func TestProjectGraph(t *testing.T) {
+	// Arrange
+	lib, api, worker, tool := newGraphProjects()
+
+	// Act
+	g := newProjectGraph([]*Project{lib, api, worker, tool})
+
+	// Assert
+	assert.Empty(t, g.dependencies[lib])
+	assert.Equal(t, []*Project{lib}, g.dependencies[api])
+	assert.Equal(t, []*Project{api, lib}, g.dependencies[worker])
+	assert.Empty(t, g.dependencies[tool])
+	assert.Equal(t, []*Project{api, worker}, g.dependents[lib])
+	assert.Equal(t, []*Project{worker}, g.dependents[api])
+	assert.Empty(t, g.dependents[tool])
+}
+
+func TestProjectGraph_Expand(t *testing.T) {
+	lib, api, worker, tool := newGraphProjects()
+	all := []*Project{lib, api, worker, tool}
+	g := newProjectGraph(all)
+
+	tests := []struct {
+		name             string
+		selected         []*Project
+		withDependents   bool
+		withDependencies bool
+		expected         []*Project
+	}{
+		{"none", []*Project{api}, false, false, []*Project{api}},
+		{"dependents", []*Project{lib}, true, false, []*Project{lib, api, worker}},
+		{"dependencies", []*Project{worker}, false, true, []*Project{lib, api, worker}},
+		{"both", []*Project{api}, true, true, []*Project{lib, api, worker}},
+		{"isolated", []*Project{tool}, true, true, []*Project{tool}},
+		{"empty", nil, true, true, []*Project{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, g.expand(all, tt.selected, tt.withDependents, tt.withDependencies))
+		})
+	}
+}
+
+func TestGetProjects_WithDependencies(t *testing.T) {
+	// Arrange
+	opt := &Options{
+		Target:           TargetNone,
+		MaxConcurrency:   2,
+		ProjectsName:     []string{"goac"},
+		WithDependents:   true,
+		WithDependencies: true,
+	}
+
+	OldRootPath := RootPath
+	RootPath = "../.."
+	defer func() { RootPath = OldRootPath }()
+
+	// Act
+	projects, err := getProjects(context.Background(), opt)
+
+	// Assert
+	assert.NoError(t, err)
+	if assert.Len(t, projects, 1) {
+		assert.Equal(t, "goac", projects[0].Name)
+	}
+}
@@ -3,42 +3,77 @@ package project
 import (
 	"encoding/hex"
 	"hash"
+	"path/filepath"
 	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/kperreau/goac/pkg/hasher"
-	"github.com/kperreau/goac/pkg/printer"
+	"github.com/kperreau/goac/pkg/logger"
 	"github.com/kperreau/goac/pkg/scan"
+	"gopkg.in/yaml.v3"
 )
 
 type Metadata struct {
 	DependenciesHash string
 	DirHash          string
-	Date             string
+	ContentHash      string           `yaml:",omitempty"`
+	Algorithm        hasher.Algorithm `yaml:",omitempty"`
+	Scheme           int              `yaml:",omitempty"`
+	// Semantic is set when the Go files were hashed without their comments and formatting.
+	Semantic bool `yaml:",omitempty"`
+	Date     string
+	// Duration is the duration of the last build of the target, used to balance shards.
+	Duration time.Duration `yaml:",omitempty"`
 }
 
+// HashSchemeVersion is the version of the way goac hashes projects.
+// It must be increased each time a change in hashing gives different hashes for the same inputs.
+const HashSchemeVersion = 5
+
 func (p *Project) LoadHashs() error {
 	depsHash, err := processDependenciesHash(p)
 	if err != nil {
-		return err
+		return &HashError{Project: p.Name, Path: p.CleanPath, Err: err}
+	}
+
+	dirHash, relDirHash, err := processDirectoryHash(p)
+	if err != nil {
+		return &HashError{Project: p.Name, Path: p.CleanPath, Err: err}
 	}
 
-	dirHash, err := processDirectoryHash(p)
+	contentHash, err := processContentHash(p, depsHash, relDirHash)
 	if err != nil {
-		return err
+		return &HashError{Project: p.Name, Path: p.CleanPath, Err: err}
 	}
 
 	p.Metadata = &Metadata{
 		DependenciesHash: depsHash,
 		DirHash:          dirHash,
+		ContentHash:      contentHash,
+		Algorithm:        p.CMDOptions.hashAlgorithm(),
+		Scheme:           HashSchemeVersion,
+		Semantic:         p.CMDOptions.semanticHash(),
 	}
+	p.CMDOptions.logger().Tracef("%s: dependencies hash %s, files hash %s, content hash %s\n", p.Name, depsHash, dirHash, contentHash)
+	p.emit(Event{Type: EventHashComputed})
 
 	return nil
 }
 
 func processDependenciesHash(p *Project) (string, error) {
-	joinedDeps := strings.Join(p.Module.ExternalDeps, ",")
+	deps := p.Module.ExternalDeps
+	if p.Module.Cgo {
+		cgoEnv, err := p.cgoEnv()
+		if err != nil {
+			return "", err
+		}
+		deps = append(slices.Clone(deps), cgoEnv...)
+	}
+	joinedDeps := strings.Join(deps, ",")
 
 	h := p.HashPool.Get().(hash.Hash)
 	defer p.HashPool.Put(h)
@@ -54,50 +89,136 @@ func processDependenciesHash(p *Project) (string, error) {
 	return hashStr, nil
 }
 
-func processDirectoryHash(p *Project) (string, error) {
-	files, err := scan.Dirs(p.Module.LocalDirs, p.Rule)
-	if err != nil {
-		return "", err
+// processDirectoryHash returns the hash of the project files, and the same hash with
+// the project files recorded relatively to the project path so that it survives a move.
+func processDirectoryHash(p *Project) (string, string, error) {
+	memo := p.HashMemo
+	if memo == nil {
+		memo = hasher.NewMemo(p.HashPool, 1).Semantic(p.CMDOptions.semanticHash()).Root(p.CMDOptions.rootPath())
 	}
 
+	rule := p.Rule
+	if rule == nil {
+		rule = &scan.Rule{}
+	}
+
+	// go files are the exact compiled files of each package, memoized per package and rule,
+	// so that projects importing the same packages share them
+	var sums []hasher.FileSum
+	for _, pkg := range p.Module.Packages {
+		pkgSums, err := memo.Dir(pkg.ImportPath+"|"+rule.Key(), func() ([]string, error) {
+			return scan.Filter(pkg.Files, rule), nil
+		})
+		if err != nil {
+			return "", "", err
+		}
+		sums = append(sums, pkgSums...)
+	}
+
+	// declared inputs are walked for non-go files only
+	inputRule := p.inputRule()
+	for _, dir := range p.inputs() {
+		dirSums, err := memo.Dir(dir+"|"+inputRule.Key(), func() ([]string, error) {
+			return p.scanInputs(dir, inputRule)
+		})
+		if err != nil {
+			return "", "", err
+		}
+		sums = append(sums, dirSums...)
+	}
+	sort.SliceStable(sums, func(i, j int) bool { return sums[i].Path < sums[j].Path })
+
 	if len(p.CMDOptions.Debug) > 0 {
+		files := make([]string, 0, len(sums))
+		for _, sum := range sums {
+			files = append(files, sum.Path)
+		}
 		debug(p, files)
 	}
 
-	hashDir, err := hasher.Files(files, p.HashPool)
+	return hasher.Combine(sums, p.HashPool, nil), hasher.Combine(sums, p.HashPool, p.relativeToProject), nil
+}
+
+func (p *Project) relativeToProject(file string) string {
+	projectPath := filepath.ToSlash(filepath.Clean(p.CleanPath))
+	if projectPath == "." || projectPath == "" {
+		return file
+	}
+
+	if rel, found := strings.CutPrefix(file, projectPath+"/"); found {
+		return "{{project-path}}/" + rel
+	}
+
+	return file
+}
+
+// processContentHash returns the content key of the project: a hash of its dependencies,
+// its files and its target config. The variables of the config are replaced, so that projects
+// writing their output in their own directory do not share a key.
+func processContentHash(p *Project, depsHash string, relDirHash string) (string, error) {
+	targetConfig, err := yaml.Marshal(resolvedTargetConfig(p))
 	if err != nil {
 		return "", err
 	}
 
-	return hashDir, nil
+	return hasher.WithPool(p.HashPool, strings.Join([]string{
+		strconv.Itoa(HashSchemeVersion),
+		strconv.FormatBool(p.CMDOptions.semanticHash()),
+		p.CMDOptions.Target.String(),
+		depsHash,
+		relDirHash,
+		string(targetConfig),
+	}, "\n"))
+}
+
+// resolvedTargetConfig returns a copy of the target config with the variables replaced,
+// without the timeout and retries which do not change the build output.
+func resolvedTargetConfig(p *Project) *TargetConfig {
+	config := p.Target[p.CMDOptions.Target]
+	if config == nil {
+		return nil
+	}
+
+	resolved := &TargetConfig{Inputs: config.Inputs}
+	for _, env := range config.Envs {
+		resolved.Envs = append(resolved.Envs, Env{Key: env.Key, Value: p.replaceVariables(env.Value)})
+	}
+	if config.Exec != nil {
+		resolved.Exec = &Exec{CMD: config.Exec.CMD}
+		for _, param := range config.Exec.Params {
+			resolved.Exec.Params = append(resolved.Exec.Params, p.replaceVariables(param))
+		}
+	}
+	return resolved
 }
 
 func debug(p *Project, files []string) {
+	log := p.CMDOptions.logger()
 	if slices.Contains(p.CMDOptions.Debug, "name") {
-		printer.Warnf("Name: %s\n", printer.BoldGreen(p.Name))
+		log.Infof("Name: %s\n", logger.BoldGreen(p.Name))
 	}
 
 	if slices.Contains(p.CMDOptions.Debug, "includes") {
-		printer.Printf("%s\n%s\n", color.YellowString("Includes"), strings.Join(p.Rule.Includes, "\n"))
+		log.Infof("%s\n%s\n", color.YellowString("Includes"), strings.Join(p.Rule.Includes, "\n"))
 	}
 
 	if slices.Contains(p.CMDOptions.Debug, "excludes") {
-		printer.Printf("%s\n%s\n", color.YellowString("Excludes"), strings.Join(p.Rule.Excludes, "\n"))
+		log.Infof("%s\n%s\n", color.YellowString("Excludes"), strings.Join(p.Rule.Excludes, "\n"))
 	}
 
 	if slices.Contains(p.CMDOptions.Debug, "hashed") {
-		printer.Printf("%s\n%s\n", color.YellowString("Hashed files"), strings.Join(files, "\n"))
+		log.Infof("%s\n%s\n", color.YellowString("Hashed files"), strings.Join(files, "\n"))
 	}
 
 	if slices.Contains(p.CMDOptions.Debug, "dependencies") {
-		printer.Printf("%s\n%s\n", color.YellowString("Dependencies"), strings.Join(p.Module.ExternalDeps, "\n"))
+		log.Infof("%s\n%s\n", color.YellowString("Dependencies"), strings.Join(p.Module.ExternalDeps, "\n"))
 	}
 
 	if slices.Contains(p.CMDOptions.Debug, "local") {
-		printer.Printf("%s\n%s\n", color.YellowString("Local Imports"), strings.Join(p.Module.LocalDirs, "\n"))
+		log.Infof("%s\n%s\n", color.YellowString("Local Imports"), strings.Join(p.Module.LocalDirs, "\n"))
 	}
 
 	if len(p.CMDOptions.Debug) > 0 {
-		printer.Printf("\n")
+		log.Infof("\n")
 	}
 }
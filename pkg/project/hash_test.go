@@ -2,13 +2,17 @@ package project
 
 import (
 	"bytes"
+	"context"
 	"crypto/sha1"
 	"io"
 	"log"
 	"os"
+	"path/filepath"
 	"sync"
 	"testing"
 
+	"github.com/kperreau/goac/pkg/hasher"
+	"github.com/kperreau/goac/pkg/logger"
 	"github.com/kperreau/goac/pkg/scan"
 	"github.com/stretchr/testify/assert"
 )
@@ -77,11 +81,38 @@ func TestProcessDependenciesHash_ValidProjectWithExternalDeps(t *testing.T) {
 	assert.Equal(t, "35380d4ef74486ae75fa80d5f4ba2c3321bf6530", hash)
 }
 
+func TestProcessDependenciesHash_CgoEnvChangesHash(t *testing.T) {
+	// Arrange
+	p := &Project{
+		Module:     &Module{ExternalDeps: []string{"dep1", "dep2"}, Cgo: true},
+		CMDOptions: &Options{Target: TargetBuild},
+		Target:     map[Target]*TargetConfig{TargetBuild: {Envs: []Env{{Key: "CGO_LDFLAGS", Value: "-lfoo"}}}},
+		HashPool: &sync.Pool{
+			New: func() any { return sha1.New() },
+		},
+	}
+
+	// Act
+	hash, err := processDependenciesHash(p)
+	p.Target[TargetBuild].Envs[0].Value = "-lbar"
+	otherHash, otherErr := processDependenciesHash(p)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NoError(t, otherErr)
+	assert.NotEqual(t, "35380d4ef74486ae75fa80d5f4ba2c3321bf6530", hash)
+	assert.NotEqual(t, hash, otherHash)
+}
+
 func TestProcessDirectoryHash_ValidProject_ReturnsHash(t *testing.T) {
 	// Arrange
 	p := &Project{
 		Module: &Module{
-			LocalDirs: []string{"../hasher", "../project"},
+			LocalDirs: []string{"../hasher", "../scan"},
+			Packages: []*Package{
+				{ImportPath: "github.com/kperreau/goac/pkg/hasher", Dir: "../hasher", Files: []string{"../hasher/hasher.go", "../hasher/memo.go"}},
+				{ImportPath: "github.com/kperreau/goac/pkg/scan", Dir: "../scan", Files: []string{"../scan/scan.go"}},
+			},
 		},
 		Rule: &scan.Rule{},
 		CMDOptions: &Options{
@@ -93,18 +124,23 @@ func TestProcessDirectoryHash_ValidProject_ReturnsHash(t *testing.T) {
 	}
 
 	// Act
-	result, err := processDirectoryHash(p)
+	result, relResult, err := processDirectoryHash(p)
 
 	// Assert
 	assert.NoError(t, err)
 	assert.NotEmpty(t, result)
+	assert.NotEmpty(t, relResult)
 }
 
 func TestProcessDirectoryHash_MultipleCallsWithSameProject_ReturnsSameHash(t *testing.T) {
 	// Arrange
 	p := &Project{
 		Module: &Module{
-			LocalDirs: []string{"../hasher", "../project"},
+			LocalDirs: []string{"../hasher", "../scan"},
+			Packages: []*Package{
+				{ImportPath: "github.com/kperreau/goac/pkg/hasher", Dir: "../hasher", Files: []string{"../hasher/hasher.go", "../hasher/memo.go"}},
+				{ImportPath: "github.com/kperreau/goac/pkg/scan", Dir: "../scan", Files: []string{"../scan/scan.go"}},
+			},
 		},
 		Rule: &scan.Rule{},
 		CMDOptions: &Options{
@@ -116,8 +152,8 @@ func TestProcessDirectoryHash_MultipleCallsWithSameProject_ReturnsSameHash(t *te
 	}
 
 	// Act
-	result1, err1 := processDirectoryHash(p)
-	result2, err2 := processDirectoryHash(p)
+	result1, _, err1 := processDirectoryHash(p)
+	result2, _, err2 := processDirectoryHash(p)
 
 	// Assert
 	assert.NoError(t, err1)
@@ -130,6 +166,9 @@ func TestProcessDirectoryHash_UnableToScanDirectories_ReturnsError(t *testing.T)
 	p := &Project{
 		Module: &Module{
 			LocalDirs: []string{"invalid_dir"},
+			Packages: []*Package{
+				{ImportPath: "invalid", Dir: "invalid_dir", Files: []string{"invalid_dir/main.go"}},
+			},
 		},
 		Rule: &scan.Rule{},
 		CMDOptions: &Options{
@@ -141,18 +180,21 @@ func TestProcessDirectoryHash_UnableToScanDirectories_ReturnsError(t *testing.T)
 	}
 
 	// Act
-	result, err := processDirectoryHash(p)
+	result, relResult, err := processDirectoryHash(p)
 
 	// Assert
 	assert.Error(t, err)
 	assert.Empty(t, result)
+	assert.Empty(t, relResult)
 }
 
 func TestDebug_ValidProjectAndFiles_PrintsDebugInformation(t *testing.T) {
+	var stdout, stderr bytes.Buffer
 	p := &Project{
 		Name: "TestProject",
 		CMDOptions: &Options{
-			Debug: []string{"name", "includes", "excludes", "hashed", "dependencies", "local"},
+			Logger: newTestLogger(logger.LevelInfo, &stdout, &stderr),
+			Debug:  []string{"name", "includes", "excludes", "hashed", "dependencies", "local"},
 		},
 		Rule: &scan.Rule{
 			Includes: []string{"include1", "include2"},
@@ -165,7 +207,7 @@ func TestDebug_ValidProjectAndFiles_PrintsDebugInformation(t *testing.T) {
 	}
 	files := []string{"file1", "file2"}
 
-	output := redirectHashStdout(debug, p, files)
+	debug(p, files)
 
 	expectedOutput := "Name: TestProject\n" +
 		"Includes\ninclude1\ninclude2\n" +
@@ -174,7 +216,8 @@ func TestDebug_ValidProjectAndFiles_PrintsDebugInformation(t *testing.T) {
 		"Dependencies\ndep1\ndep2\n" +
 		"Local Imports\nlocal1\nlocal2\n\n"
 
-	assert.Equal(t, expectedOutput, output.String())
+	assert.Empty(t, stdout.String())
+	assert.Equal(t, expectedOutput, stderr.String())
 }
 
 func TestDebug_NoDebugOptionsSpecified_DoesNotPrintDebugInformation(t *testing.T) {
@@ -201,10 +244,12 @@ func TestDebug_NoDebugOptionsSpecified_DoesNotPrintDebugInformation(t *testing.T
 }
 
 func TestDebug_EmptyFilesList_PrintsNoHashedFiles(t *testing.T) {
+	var stdout, stderr bytes.Buffer
 	p := &Project{
 		Name: "TestProject",
 		CMDOptions: &Options{
-			Debug: []string{"hashed"},
+			Logger: newTestLogger(logger.LevelInfo, &stdout, &stderr),
+			Debug:  []string{"hashed"},
 		},
 		Rule: &scan.Rule{
 			Includes: []string{"include1", "include2"},
@@ -217,10 +262,11 @@ func TestDebug_EmptyFilesList_PrintsNoHashedFiles(t *testing.T) {
 	}
 	var files []string
 
-	output := redirectHashStdout(debug, p, files)
+	debug(p, files)
 	expectedOutput := "Hashed files\n\n\n"
 
-	assert.Equal(t, expectedOutput, output.String())
+	assert.Empty(t, stdout.String())
+	assert.Equal(t, expectedOutput, stderr.String())
 }
 
 func redirectHashStdout(f func(*Project, []string), p *Project, files []string) *bytes.Buffer {
@@ -246,3 +292,219 @@ func redirectHashStdout(f func(*Project, []string), p *Project, files []string)
 
 	return &buf
 }
+
+func TestRelativeToProject_FileInProject(t *testing.T) {
+	p := &Project{CleanPath: "services/api"}
+
+	assert.Equal(t, "{{project-path}}/main.go", p.relativeToProject("services/api/main.go"))
+	assert.Equal(t, "pkg/lib/lib.go", p.relativeToProject("pkg/lib/lib.go"))
+	assert.Equal(t, "services/api-v2/main.go", p.relativeToProject("services/api-v2/main.go"))
+}
+
+func TestRelativeToProject_RootProject(t *testing.T) {
+	p := &Project{CleanPath: "."}
+
+	assert.Equal(t, "main.go", p.relativeToProject("main.go"))
+}
+
+func TestProcessContentHash_DependsOnTargetConfig(t *testing.T) {
+	// Arrange
+	p := &Project{
+		Target: map[Target]*TargetConfig{
+			TargetBuild:      {Exec: &Exec{CMD: "go", Params: []string{"build"}}},
+			TargetBuildImage: {Exec: &Exec{CMD: "docker"}},
+		},
+		CMDOptions: &Options{Target: TargetBuild},
+		HashPool: &sync.Pool{
+			New: func() any { return sha1.New() },
+		},
+	}
+
+	// Act
+	buildHash, err1 := processContentHash(p, "deps", "dir")
+	p.CMDOptions.Target = TargetBuildImage
+	imageHash, err2 := processContentHash(p, "deps", "dir")
+
+	// Assert
+	assert.NoError(t, err1)
+	assert.NoError(t, err2)
+	assert.NotEqual(t, buildHash, imageHash)
+}
+
+func TestProcessDirectoryHash_SharedMemoSameHash(t *testing.T) {
+	// Arrange
+	hashPool := &sync.Pool{
+		New: func() any { return sha1.New() },
+	}
+	p := &Project{
+		Module: &Module{
+			Packages: []*Package{
+				{ImportPath: "github.com/kperreau/goac/pkg/hasher", Dir: "../hasher", Files: []string{"../hasher/hasher.go", "../hasher/memo.go"}},
+				{ImportPath: "github.com/kperreau/goac/pkg/scan", Dir: "../scan", Files: []string{"../scan/scan.go"}},
+			},
+		},
+		Rule:       &scan.Rule{},
+		CMDOptions: &Options{},
+		HashPool:   hashPool,
+	}
+
+	// Act
+	withoutMemo, _, err1 := processDirectoryHash(p)
+	p.HashMemo = hasher.NewMemo(hashPool, 4)
+	withMemo, _, err2 := processDirectoryHash(p)
+
+	// Assert
+	assert.NoError(t, err1)
+	assert.NoError(t, err2)
+	assert.Equal(t, withoutMemo, withMemo)
+}
+
+func TestProcessDirectoryHash_OnlyCompiledFilesAndNonGoInputs(t *testing.T) {
+	// Arrange
+	var stdout, stderr bytes.Buffer
+	p := &Project{
+		CleanPath: "../scan",
+		Target: map[Target]*TargetConfig{
+			TargetBuildImage: {Inputs: []string{"{{project-path}}"}},
+		},
+		Module: &Module{
+			Packages: []*Package{
+				{ImportPath: "github.com/kperreau/goac/pkg/hasher", Dir: "../hasher", Files: []string{"../hasher/hasher.go"}},
+			},
+		},
+		Rule:       &scan.Rule{Excludes: []string{"*_test.go"}},
+		CMDOptions: &Options{Target: TargetBuildImage, Debug: []string{"hashed"}, Logger: newTestLogger(logger.LevelInfo, &stdout, &stderr)},
+		HashPool: &sync.Pool{
+			New: func() any { return sha1.New() },
+		},
+	}
+
+	// Act
+	_, _, err := processDirectoryHash(p)
+
+	// Assert that go files of the input directory are not hashed, only those of the packages
+	assert.NoError(t, err)
+	assert.Empty(t, stdout.String())
+	assert.Equal(t, "Hashed files\n../hasher/hasher.go\n\n", stderr.String())
+}
+
+func TestProcessDirectoryHash_PackageFilesFilteredByRule(t *testing.T) {
+	// Arrange
+	var stdout, stderr bytes.Buffer
+	p := &Project{
+		Module: &Module{
+			Packages: []*Package{
+				{ImportPath: "github.com/kperreau/goac/pkg/hasher", Dir: "../hasher", Files: []string{"../hasher/hasher.go", "../hasher/memo.go"}},
+			},
+		},
+		Rule:       &scan.Rule{Excludes: []string{"memo.go"}},
+		CMDOptions: &Options{Target: TargetBuild, Debug: []string{"hashed"}, Logger: newTestLogger(logger.LevelInfo, &stdout, &stderr)},
+		HashPool: &sync.Pool{
+			New: func() any { return sha1.New() },
+		},
+	}
+
+	// Act
+	_, _, err := processDirectoryHash(p)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Empty(t, stdout.String())
+	assert.Equal(t, "Hashed files\n../hasher/hasher.go\n\n", stderr.String())
+}
+
+func TestProcessContentHash_IgnoresTimeoutAndRetries(t *testing.T) {
+	// Arrange
+	p := &Project{
+		CMDOptions: &Options{Target: TargetBuild},
+		Target:     map[Target]*TargetConfig{TargetBuild: {Exec: &Exec{CMD: "go"}}},
+		HashPool:   hasher.NewPool(),
+	}
+
+	// Act
+	hash, err := processContentHash(p, "deps", "dir")
+	p.Target[TargetBuild].Timeout, p.Target[TargetBuild].Retries = "10m", 3
+	otherHash, otherErr := processContentHash(p, "deps", "dir")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NoError(t, otherErr)
+	assert.Equal(t, hash, otherHash)
+}
+
+func TestProcessContentHash_ProjectsFromOneTemplate(t *testing.T) {
+	// Arrange
+	newProject := func(name string) *Project {
+		return &Project{
+			Name:       name,
+			Path:       "./services/" + name,
+			CMDOptions: &Options{Target: TargetBuild},
+			Target: map[Target]*TargetConfig{TargetBuild: {
+				Envs: []Env{{Key: "NAME", Value: "{{project-name}}"}},
+				Exec: &Exec{CMD: "go", Params: []string{"build", "-o", "{{project-path}}/{{project-name}}", "{{project-path}}"}},
+			}},
+			HashPool: hasher.NewPool(),
+		}
+	}
+	auth, users := newProject("auth"), newProject("users")
+
+	// Act
+	authHash, authErr := processContentHash(auth, "deps", "dir")
+	usersHash, usersErr := processContentHash(users, "deps", "dir")
+
+	// Assert
+	assert.NoError(t, authErr)
+	assert.NoError(t, usersErr)
+	assert.NotEqual(t, authHash, usersHash)
+	assert.Equal(t, "{{project-path}}/{{project-name}}", auth.Target[TargetBuild].Exec.Params[2])
+}
+
+func TestIsContentCached_OtherProjectFromOneTemplate(t *testing.T) {
+	// Arrange
+	root := newWorkspaceRepo(t)
+	template := "target:\n  build:\n    exec:\n      cmd: touch\n      params: [\"{{project-path}}/built\"]\n"
+	for _, name := range []string{"auth", "users"} {
+		assert.NoError(t, os.MkdirAll(filepath.Join(root, name), 0o755))
+		assert.NoError(t, os.WriteFile(filepath.Join(root, name, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0o644))
+		assert.NoError(t, os.WriteFile(filepath.Join(root, name, configFileName), []byte("version: 1.0\nname: "+name+"\n"+template), 0o644))
+	}
+	w := NewWorkspace(WorkspaceOptions{Root: root, CachePath: t.TempDir()})
+	opt := Options{Target: TargetBuild, NoDaemon: true, Paths: []string{"auth"}}
+
+	// Act
+	_, err := w.Build(context.Background(), opt)
+	assert.NoError(t, err)
+	opt.Paths = []string{"users"}
+	affected, err := w.Affected(context.Background(), opt)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, affected, 1)
+	assert.Equal(t, "users", affected[0].Name)
+}
+
+func TestProcessContentHash_DependsOnSemanticMode(t *testing.T) {
+	// Arrange
+	p := &Project{
+		CMDOptions: &Options{Target: TargetBuild},
+		Target:     map[Target]*TargetConfig{TargetBuild: {Exec: &Exec{CMD: "go"}}},
+		HashPool:   hasher.NewPool(),
+	}
+
+	// Act
+	hash, err := processContentHash(p, "deps", "dir")
+	p.CMDOptions.SemanticHash = true
+	semanticHash, semanticErr := processContentHash(p, "deps", "dir")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NoError(t, semanticErr)
+	assert.NotEqual(t, hash, semanticHash)
+}
+
+func TestIsMetadataMatch_SemanticMode(t *testing.T) {
+	cached := &Metadata{DependenciesHash: "deps", DirHash: "dir", Algorithm: hasher.SHA1, Scheme: HashSchemeVersion}
+
+	assert.True(t, cached.isMetadataMatch(&Metadata{DependenciesHash: "deps", DirHash: "dir", Algorithm: hasher.SHA1, Scheme: HashSchemeVersion}))
+	assert.False(t, cached.isMetadataMatch(&Metadata{DependenciesHash: "deps", DirHash: "dir", Algorithm: hasher.SHA1, Scheme: HashSchemeVersion, Semantic: true}))
+}
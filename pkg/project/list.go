@@ -1,15 +1,22 @@
 package project
 
 import (
+	"strings"
+
 	"github.com/fatih/color"
-	"github.com/kperreau/goac/pkg/printer"
 )
 
 const configFileName = ".goacproject.yaml"
 
 func (l *List) List() {
-	printer.Printf("Found %s projects\n", color.YellowString("%d", len(l.Projects)))
+	log := l.Options.logger()
+	log.Printf("Found %s projects\n", color.YellowString("%d", len(l.Projects)))
 	for _, project := range l.Projects {
-		printer.Printf("%s %s %s\n", color.BlueString(project.Name), color.YellowString("=>"), project.CleanPath)
+		if len(project.Tags) == 0 {
+			log.Printf("%s %s %s\n", color.BlueString(project.Name), color.YellowString("=>"), project.CleanPath)
+			continue
+		}
+		log.Printf("%s %s %s %s\n", color.BlueString(project.Name), color.YellowString("=>"), project.CleanPath,
+			color.HiBlackString("[%s]", strings.Join(project.Tags, ", ")))
 	}
 }
@@ -7,6 +7,7 @@ import (
 	"os"
 	"testing"
 
+	"github.com/kperreau/goac/pkg/logger"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -84,3 +85,19 @@ func redirectStdout(f func()) bytes.Buffer {
 
 	return buf
 }
+
+// newTestLogger returns a logger printing the data output to stdout, and the messages of the level to stderr.
+func newTestLogger(level logger.Level, stdout, stderr *bytes.Buffer) *logger.Logger {
+	return logger.New(logger.Options{Level: level, NoColor: true, Stdout: stdout, Stderr: stderr})
+}
+
+func TestList_PrintsTags(t *testing.T) {
+	l := &List{
+		Projects: []*Project{{Name: "auth", CleanPath: "services/auth", Tags: []string{"backend", "go"}}},
+		Options:  &Options{},
+	}
+
+	buf := redirectStdout(l.List)
+
+	assert.Equal(t, "Found 1 projects\nauth => services/auth [backend, go]\n", buf.String())
+}
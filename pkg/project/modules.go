@@ -1,58 +1,245 @@
 package project
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"slices"
 	"strings"
+	"sync"
 
 	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
 )
 
 type Module struct {
 	LocalDirs      []string
 	ExternalDeps   []string
 	IgnoredGoFiles []string
+	Packages       []*Package `yaml:",omitempty"`
+	Cgo            bool       `yaml:",omitempty"`
+}
+
+// Package is a local package compiled in the project, with the exact list of its compiled files.
+type Package struct {
+	ImportPath string
+	Dir        string
+	Files      []string
 }
 
 type toolData struct {
 	ImportPath string
+	Dir        string
+	DepOnly    bool
 	Module     struct {
 		Path string
 		Dir  string
 	}
 	GoFiles        []string
+	CgoFiles       []string
+	CFiles         []string
+	CXXFiles       []string
+	MFiles         []string
+	HFiles         []string
+	FFiles         []string
+	SFiles         []string
+	SwigFiles      []string
+	SwigCXXFiles   []string
+	SysoFiles      []string
+	EmbedFiles     []string
 	IgnoredGoFiles []string
 	Imports        []string
 	Deps           []string
 }
 
-func (p *Project) LoadGOModules(gomod *modfile.File) error {
-	cmd := exec.Command("go", "list", "-json", p.Path)
-	output, err := cmd.Output()
+func (p *Project) LoadGOModules(ctx context.Context, gomod *modfile.File) error {
+	root, err := filepath.Abs(p.CMDOptions.rootPath())
 	if err != nil {
 		return err
 	}
 
-	var rawData toolData
-	if err := json.Unmarshal(output, &rawData); err != nil {
-		return err
+	cmd := exec.CommandContext(ctx, "go", "list", "-deps", "-json", p.Path)
+	cmd.Dir = root
+	output, err := cmd.Output()
+	if err != nil {
+		moduleErr := &ModuleError{Project: p.Name, Path: p.CleanPath, Command: cmd.String(), Err: err}
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			moduleErr.Stderr = string(exitErr.Stderr)
+		}
+		return moduleErr
+	}
+
+	packages, err := decodeToolData(output)
+	if err != nil {
+		return &ModuleError{Project: p.Name, Path: p.CleanPath, Err: err}
 	}
 
-	localDir, extDeps := cleanDeps(&rawData, p.Path)
+	// the project package is the only one not listed as a dependency
+	rawData := &toolData{}
+	for _, pkg := range packages {
+		if !pkg.DepOnly {
+			rawData = pkg
+		}
+	}
+
+	localDir, extDeps := cleanDeps(rawData, p.Path)
+
+	localPackages, err := getLocalPackages(packages, root, rawData.Module.Path, rawData.Module.Dir)
+	if err != nil {
+		return &ModuleError{Project: p.Name, Path: p.CleanPath, Err: err}
+	}
 
 	p.Module = &Module{
 		LocalDirs:      localDir,
 		ExternalDeps:   getDependencies(gomod, extDeps),
 		IgnoredGoFiles: rawData.IgnoredGoFiles,
+		Packages:       localPackages,
+		Cgo:            usesCgo(packages),
 	}
+	p.CMDOptions.logger().Tracef("%s: %d local packages, %d external dependencies\n", p.Name, len(localPackages), len(p.Module.ExternalDeps))
 
 	return nil
 }
 
+// decodeToolData decodes the stream of JSON objects printed by go list.
+func decodeToolData(output []byte) (packages []*toolData, err error) {
+	decoder := json.NewDecoder(bytes.NewReader(output))
+	for decoder.More() {
+		var rawData toolData
+		if err := decoder.Decode(&rawData); err != nil {
+			return nil, err
+		}
+		packages = append(packages, &rawData)
+	}
+	return packages, nil
+}
+
+// getLocalPackages returns the packages of the module and the vendored packages with their compiled files,
+// relative to the root directory.
+func getLocalPackages(packages []*toolData, root string, modulePath string, moduleDir string) ([]*Package, error) {
+	if modulePath == "" {
+		return nil, nil
+	}
+
+	var localPackages []*Package
+	for _, pkg := range packages {
+		if pkg.Module.Path != modulePath && !isVendored(pkg, moduleDir) {
+			continue
+		}
+
+		dir, err := filepath.Rel(root, pkg.Dir)
+		if err != nil {
+			return nil, err
+		}
+
+		localPackage := &Package{ImportPath: pkg.ImportPath, Dir: filepath.ToSlash(dir)}
+		for _, files := range pkg.compiledFiles() {
+			for _, file := range files {
+				localPackage.Files = append(localPackage.Files, filepath.ToSlash(filepath.Join(dir, file)))
+			}
+		}
+		localPackages = append(localPackages, localPackage)
+	}
+
+	return localPackages, nil
+}
+
+// isVendored reports if a package is read from the vendor directory of the module.
+func isVendored(pkg *toolData, moduleDir string) bool {
+	if moduleDir == "" {
+		return false
+	}
+	rel, err := filepath.Rel(filepath.Join(moduleDir, "vendor"), pkg.Dir)
+	return err == nil && rel != "." && !strings.HasPrefix(rel, "..")
+}
+
+// compiledFiles returns the go sources, the non-go sources compiled with cgo, the syso and the embedded files.
+func (t *toolData) compiledFiles() [][]string {
+	return [][]string{
+		t.GoFiles, t.CgoFiles,
+		t.CFiles, t.CXXFiles, t.MFiles, t.HFiles, t.FFiles, t.SFiles, t.SwigFiles, t.SwigCXXFiles, t.SysoFiles,
+		t.EmbedFiles,
+	}
+}
+
+// usesCgo reports if a package of the build, local or not, uses cgo. The standard library packages
+// using cgo, like net or os/user, count too: CGO_ENABLED switches them to their pure Go fallbacks.
+func usesCgo(packages []*toolData) bool {
+	for _, pkg := range packages {
+		if len(pkg.CgoFiles) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// cgoEnvKeys are the environment variables changing the output of a cgo build.
+var cgoEnvKeys = []string{
+	"CGO_ENABLED", "CC", "CXX", "CGO_CFLAGS", "CGO_CPPFLAGS", "CGO_CXXFLAGS", "CGO_FFLAGS", "CGO_LDFLAGS", "PKG_CONFIG",
+}
+
+// goEnv reads GOFLAGS and the cgo environment once per run, including the values set with go env -w.
+var goEnv = sync.OnceValues(func() (map[string]string, error) {
+	return readGoEnv(nil, append([]string{"GOFLAGS"}, cgoEnvKeys...)...)
+})
+
+// readGoEnv returns the values of the keys given by go env, run with environ or with the environment
+// of goac if nil.
+func readGoEnv(environ []string, keys ...string) (map[string]string, error) {
+	cmd := exec.Command("go", append([]string{"env", "-json"}, keys...)...)
+	cmd.Env = environ
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("error reading go env: %w", err)
+	}
+
+	env := map[string]string{}
+	if err := json.Unmarshal(output, &env); err != nil {
+		return nil, fmt.Errorf("error reading go env: %w", err)
+	}
+	return env, nil
+}
+
+// goEnv returns the go env of the run: the one read by the daemon for its load, or the one of goac.
+func (o *Options) goEnv() (map[string]string, error) {
+	if o != nil && o.env != nil {
+		return o.env, nil
+	}
+	return goEnv()
+}
+
+// cgoEnv returns the cgo environment of the build as sorted KEY=VALUE pairs,
+// the envs of the target config override the go env.
+func (p *Project) cgoEnv() ([]string, error) {
+	env, err := p.CMDOptions.goEnv()
+	if err != nil {
+		return nil, err
+	}
+	env = maps.Clone(env)
+
+	if p.CMDOptions != nil && p.Target[p.CMDOptions.Target] != nil {
+		for _, e := range p.Target[p.CMDOptions.Target].Envs {
+			if slices.Contains(cgoEnvKeys, e.Key) {
+				env[e.Key] = e.Value
+			}
+		}
+	}
+
+	pairs := make([]string, 0, len(cgoEnvKeys))
+	for _, key := range cgoEnvKeys {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", key, env[key]))
+	}
+	return pairs, nil
+}
+
 func loadGOModFile(path string) (*modfile.File, error) {
 	data, err := os.ReadFile(filepath.Join(path, "go.mod"))
 	if err != nil {
@@ -67,6 +254,61 @@ func loadGOModFile(path string) (*modfile.File, error) {
 	return modFile, nil
 }
 
+// loadVendorModules returns the go.mod file with the module versions read from vendor/modules.txt
+// when the build uses the vendor directory, or gomod unchanged.
+func loadVendorModules(opt *Options, gomod *modfile.File) (*modfile.File, error) {
+	env, err := opt.goEnv()
+	if err != nil {
+		return nil, err
+	}
+	path := opt.rootPath()
+	if !isVendorEnabled(path, gomod, env["GOFLAGS"]) {
+		return gomod, nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(path, "vendor", "modules.txt"))
+	if err != nil {
+		return nil, fmt.Errorf("error reading vendor/modules.txt: %v", err)
+	}
+
+	return &modfile.File{
+		Module:  gomod.Module,
+		Go:      gomod.Go,
+		Require: parseVendorModules(data),
+	}, nil
+}
+
+// isVendorEnabled follows the go command: -mod in GOFLAGS wins, otherwise the vendor directory
+// is used if it exists and the go version of the module is at least 1.14.
+func isVendorEnabled(path string, gomod *modfile.File, goflags string) bool {
+	for _, flag := range strings.Fields(goflags) {
+		if mode, ok := strings.CutPrefix(strings.TrimLeft(flag, "-"), "mod="); ok {
+			return mode == "vendor"
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(path, "vendor", "modules.txt")); err != nil {
+		return false
+	}
+
+	return gomod.Go != nil && semver.Compare("v"+gomod.Go.Version, "v1.14") >= 0
+}
+
+// parseVendorModules reads the modules of vendor/modules.txt, the version of a replaced
+// module includes its replacement, like "v1.0.0 => ../fork".
+func parseVendorModules(data []byte) (requires []*modfile.Require) {
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || fields[0] != "#" {
+			continue
+		}
+		requires = append(requires, &modfile.Require{
+			Mod: module.Version{Path: fields[1], Version: strings.Join(fields[2:], " ")},
+		})
+	}
+	return requires
+}
+
 func cleanDeps(rawData *toolData, localDir string) (localDeps []string, extDeps []string) {
 	localDeps = []string{filepath.Clean(localDir)}
 	deps := append(rawData.Deps, rawData.Imports...)
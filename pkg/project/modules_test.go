@@ -1,6 +1,7 @@
 package project
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -21,7 +22,7 @@ func TestLoadGOModules_LoadsModuleData(t *testing.T) {
 	mfile, _ := loadGOModFile("../..")
 
 	// Call the method under test
-	err := p.LoadGOModules(mfile)
+	err := p.LoadGOModules(context.Background(), mfile)
 
 	// Assert that there is no error
 	assert.NoError(t, err)
@@ -32,6 +33,15 @@ func TestLoadGOModules_LoadsModuleData(t *testing.T) {
 	assert.Contains(t, p.Module.LocalDirs, "./pkg/project")
 	assert.Contains(t, p.Module.LocalDirs, "./pkg/hasher")
 	assert.NotEmpty(t, p.Module.ExternalDeps)
+
+	// Assert that the compiled files of local packages are listed
+	var files []string
+	for _, pkg := range p.Module.Packages {
+		files = append(files, pkg.Files...)
+	}
+	assert.Contains(t, files, "../../main.go")
+	assert.Contains(t, files, "hash.go")
+	assert.NotContains(t, files, "hash_test.go")
 }
 
 func TestLoadGOModFile_Valid(t *testing.T) {
@@ -201,3 +211,132 @@ func TestFindVersion_EmptyString(t *testing.T) {
 
 	assert.Empty(t, result)
 }
+
+func TestDecodeToolData_MultiplePackages(t *testing.T) {
+	output := []byte(`{"ImportPath": "a", "DepOnly": true}
+{"ImportPath": "b"}`)
+
+	packages, err := decodeToolData(output)
+
+	assert.NoError(t, err)
+	assert.Len(t, packages, 2)
+	assert.True(t, packages[0].DepOnly)
+	assert.Equal(t, "b", packages[1].ImportPath)
+}
+
+func TestDecodeToolData_InvalidJSON(t *testing.T) {
+	_, err := decodeToolData([]byte(`{"ImportPath": `))
+
+	assert.Error(t, err)
+}
+
+func TestGetLocalPackages_OnlyModulePackages(t *testing.T) {
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+
+	local := &toolData{ImportPath: "example.com/app/lib", Dir: filepath.Join(wd, "lib")}
+	local.Module.Path = "example.com/app"
+	local.GoFiles = []string{"lib.go"}
+	local.CgoFiles = []string{"cgo.go"}
+	local.CFiles = []string{"lib.c"}
+	local.HFiles = []string{"lib.h"}
+	local.SFiles = []string{"asm_amd64.s"}
+	local.SysoFiles = []string{"lib.syso"}
+	local.EmbedFiles = []string{"static/index.html"}
+	external := &toolData{ImportPath: "example.com/other", Dir: "/go/pkg/mod/example.com/other"}
+	external.Module.Path = "example.com/other"
+	std := &toolData{ImportPath: "fmt", Dir: "/usr/local/go/src/fmt"}
+
+	packages, err := getLocalPackages([]*toolData{std, external, local}, wd, "example.com/app", wd)
+
+	assert.NoError(t, err)
+	assert.Len(t, packages, 1)
+	assert.Equal(t, "lib", packages[0].Dir)
+	assert.Equal(t, []string{"lib/lib.go", "lib/cgo.go", "lib/lib.c", "lib/lib.h", "lib/asm_amd64.s", "lib/lib.syso", "lib/static/index.html"}, packages[0].Files)
+}
+
+func TestGetLocalPackages_NoModule(t *testing.T) {
+	packages, err := getLocalPackages([]*toolData{{ImportPath: "fmt"}}, "", "", "")
+
+	assert.NoError(t, err)
+	assert.Empty(t, packages)
+}
+
+func TestUsesCgo(t *testing.T) {
+	assert.False(t, usesCgo([]*toolData{{ImportPath: "fmt", GoFiles: []string{"print.go"}}}))
+	assert.True(t, usesCgo([]*toolData{{ImportPath: "fmt"}, {ImportPath: "example.com/sqlite", CgoFiles: []string{"sqlite.go"}}}))
+}
+
+func TestUsesCgo_StandardLibrary(t *testing.T) {
+	assert.True(t, usesCgo([]*toolData{{ImportPath: "net", CgoFiles: []string{"cgo_unix.go"}}}))
+}
+
+func TestCgoEnv_TargetEnvsOverrideGoEnv(t *testing.T) {
+	// Arrange
+	p := &Project{
+		CMDOptions: &Options{Target: TargetBuild},
+		Target: map[Target]*TargetConfig{
+			TargetBuild: {Envs: []Env{{Key: "CGO_CFLAGS", Value: "-O3"}, {Key: "GOOS", Value: "linux"}}},
+		},
+	}
+
+	// Act
+	env, err := p.cgoEnv()
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, env, len(cgoEnvKeys))
+	assert.Contains(t, env, "CGO_CFLAGS=-O3")
+	assert.NotContains(t, env, "GOOS=linux")
+}
+
+func TestGetLocalPackages_IncludesVendoredPackages(t *testing.T) {
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+
+	local := &toolData{ImportPath: "example.com/app", Dir: wd, GoFiles: []string{"main.go"}}
+	local.Module.Path = "example.com/app"
+	vendored := &toolData{ImportPath: "example.com/other", Dir: filepath.Join(wd, "vendor", "example.com", "other"), GoFiles: []string{"other.go"}}
+	vendored.Module.Path = "example.com/other"
+	std := &toolData{ImportPath: "golang.org/x/net/dns", Dir: "/usr/local/go/src/vendor/golang.org/x/net/dns"}
+
+	packages, err := getLocalPackages([]*toolData{std, vendored, local}, wd, "example.com/app", wd)
+
+	assert.NoError(t, err)
+	assert.Len(t, packages, 2)
+	assert.Equal(t, []string{"vendor/example.com/other/other.go"}, packages[0].Files)
+	assert.Equal(t, []string{"main.go"}, packages[1].Files)
+}
+
+func TestIsVendorEnabled(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	gomod := &modfile.File{Go: &modfile.Go{Version: "1.22"}}
+	legacy := &modfile.File{Go: &modfile.Go{Version: "1.13"}}
+
+	// Act & Assert
+	assert.False(t, isVendorEnabled(dir, gomod, ""))
+	assert.True(t, isVendorEnabled(dir, gomod, "-mod=vendor"))
+
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "vendor"), 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "vendor", "modules.txt"), nil, 0o644))
+	assert.True(t, isVendorEnabled(dir, gomod, ""))
+	assert.False(t, isVendorEnabled(dir, legacy, ""))
+	assert.False(t, isVendorEnabled(dir, gomod, "-trimpath -mod=mod"))
+}
+
+func TestParseVendorModules(t *testing.T) {
+	data := []byte(`# github.com/fatih/color v1.17.0
+## explicit; go 1.17
+github.com/fatih/color
+# example.com/fork v1.0.0 => ../fork
+## explicit
+example.com/fork
+`)
+
+	requires := parseVendorModules(data)
+
+	assert.Len(t, requires, 2)
+	assert.Equal(t, module.Version{Path: "github.com/fatih/color", Version: "v1.17.0"}, requires[0].Mod)
+	assert.Equal(t, module.Version{Path: "example.com/fork", Version: "v1.0.0 => ../fork"}, requires[1].Mod)
+}
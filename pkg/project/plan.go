@@ -0,0 +1,216 @@
+package project
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kperreau/goac/pkg/hasher"
+)
+
+// PlanVersion is the version of the plan files schema.
+const PlanVersion = 1
+
+// Plan is the build of the affected projects of a target, recorded to be applied later.
+// It holds the resolved commands of the projects and the hashes of their inputs,
+// the plan is not applied if the inputs changed since it was made.
+type Plan struct {
+	Version int       `json:"version"`
+	Target  Target    `json:"target"`
+	Created time.Time `json:"created"`
+	// HashAlgorithm, SemanticHash and DockerIgnore are the hashing options of the plan,
+	// the inputs are hashed with them again when the plan is applied.
+	HashAlgorithm hasher.Algorithm  `json:"hashAlgorithm"`
+	SemanticHash  bool              `json:"semanticHash"`
+	DockerIgnore  bool              `json:"dockerignore"`
+	Projects      []*PlannedProject `json:"projects"`
+}
+
+// PlannedProject is an affected project of a plan, with its resolved target command.
+type PlannedProject struct {
+	Name   string `json:"name"`
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+	// Command is the command line, for display. Cmd and Params are run.
+	Command string   `json:"command"`
+	Cmd     string   `json:"cmd"`
+	Params  []string `json:"params,omitempty"`
+	// Envs are the KEY=value variables of the target, added to the environment of goac.
+	Envs             []string `json:"envs,omitempty"`
+	DependenciesHash string   `json:"dependenciesHash"`
+	DirHash          string   `json:"dirHash"`
+	ContentHash      string   `json:"contentHash"`
+}
+
+// Plan returns the plan of the affected projects defining the target.
+func (l *List) Plan() *Plan {
+	plan := &Plan{
+		Version:       PlanVersion,
+		Target:        l.Options.Target,
+		Created:       time.Now().UTC(),
+		HashAlgorithm: l.Options.hashAlgorithm(),
+		SemanticHash:  l.Options.SemanticHash,
+		DockerIgnore:  l.Options.DockerIgnore,
+		Projects:      []*PlannedProject{},
+	}
+	for _, p := range l.Projects {
+		if p.Target[l.Options.Target] == nil || !p.isAffected() {
+			continue
+		}
+		plan.Projects = append(plan.Projects, newPlannedProject(p))
+	}
+	return plan
+}
+
+func newPlannedProject(p *Project) *PlannedProject {
+	config := p.Target[p.CMDOptions.Target]
+	planned := &PlannedProject{
+		Name:             p.Name,
+		Path:             p.CleanPath,
+		Reason:           p.affectedReason(),
+		DependenciesHash: p.Metadata.DependenciesHash,
+		DirHash:          p.Metadata.DirHash,
+		ContentHash:      p.Metadata.ContentHash,
+	}
+	if config.Exec != nil {
+		planned.Cmd = config.Exec.CMD
+		for _, param := range config.Exec.Params {
+			planned.Params = append(planned.Params, p.replaceVariables(param))
+		}
+		planned.Command = strings.Join(append([]string{planned.Cmd}, planned.Params...), " ")
+	}
+	for _, env := range config.Envs {
+		planned.Envs = append(planned.Envs, fmt.Sprintf("%s=%s", env.Key, p.replaceVariables(env.Value)))
+	}
+	return planned
+}
+
+// JSON returns the indented JSON of the plan.
+func (p *Plan) JSON() ([]byte, error) {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// WriteFile writes the plan to a JSON file.
+func (p *Plan) WriteFile(file string) error {
+	data, err := p.JSON()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, data, 0o644)
+}
+
+// ReadPlan reads a plan written by WriteFile.
+func ReadPlan(file string) (*Plan, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("error reading plan: %w", err)
+	}
+
+	var plan Plan
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, fmt.Errorf("error reading plan %s: %w", file, err)
+	}
+	if plan.Version != PlanVersion {
+		return nil, fmt.Errorf("unsupported plan version %d, expected %d", plan.Version, PlanVersion)
+	}
+	if plan.Target != TargetBuild && plan.Target != TargetBuildImage {
+		return nil, fmt.Errorf("invalid plan target %q", plan.Target)
+	}
+	return &plan, nil
+}
+
+// OutdatedPlanError reports the planned projects whose inputs changed since the plan was made.
+type OutdatedPlanError struct {
+	// Changes are the changes of each outdated project, like "api: files changed".
+	Changes []string
+}
+
+func (e *OutdatedPlanError) Error() string {
+	return fmt.Sprintf("plan is outdated, the inputs changed since it was made:\n  %s", strings.Join(e.Changes, "\n  "))
+}
+
+// NewPlanList loads the projects of the plan and returns the list building them with the commands
+// of the plan. The target, the hashing options and the selection of opt are replaced by those of
+// the plan, an OutdatedPlanError is returned if the hashes of the inputs changed.
+func NewPlanList(ctx context.Context, plan *Plan, opt *Options) (IList, error) {
+	opt.Target = plan.Target
+	opt.HashAlgorithm, opt.SemanticHash, opt.DockerIgnore = plan.HashAlgorithm, plan.SemanticHash, plan.DockerIgnore
+	// the plan decided which projects are built, they are built whatever the state of the cache
+	opt.Force, opt.DryRun = true, false
+	opt.ProjectsName, opt.Tags, opt.Exclude = nil, nil, nil
+	opt.WithDependents, opt.WithDependencies, opt.Shard = false, false, nil
+	if len(plan.Projects) == 0 {
+		return &List{Options: opt}, nil
+	}
+
+	opt.Paths = make([]string, 0, len(plan.Projects))
+	for _, planned := range plan.Projects {
+		opt.Paths = append(opt.Paths, planned.Path)
+	}
+	l, err := NewProjectsList(ctx, opt)
+	if err != nil {
+		return nil, err
+	}
+
+	// paths are prefixes, the projects nested in the planned ones are loaded too
+	loaded := map[string]*Project{}
+	for _, p := range l.(*List).Projects {
+		loaded[p.CleanPath] = p
+	}
+
+	projects := make([]*Project, 0, len(plan.Projects))
+	var changes []string
+	for _, planned := range plan.Projects {
+		p := loaded[planned.Path]
+		if change := planned.change(p); change != "" {
+			changes = append(changes, fmt.Sprintf("%s: %s", planned.Name, change))
+			continue
+		}
+		planned.apply(p)
+		projects = append(projects, p)
+	}
+	if len(changes) > 0 {
+		return nil, &OutdatedPlanError{Changes: changes}
+	}
+
+	return &List{Projects: projects, Options: opt}, nil
+}
+
+// change explains how the project changed since it was planned, it is empty if it did not change.
+func (planned *PlannedProject) change(p *Project) string {
+	switch {
+	case p == nil:
+		return "project not found"
+	case p.Target[p.CMDOptions.Target] == nil:
+		return fmt.Sprintf("target %s not defined", p.CMDOptions.Target)
+	case p.Metadata.DependenciesHash != planned.DependenciesHash && p.Metadata.DirHash != planned.DirHash:
+		return "dependencies and files changed"
+	case p.Metadata.DependenciesHash != planned.DependenciesHash:
+		return "dependencies changed"
+	case p.Metadata.DirHash != planned.DirHash:
+		return "files changed"
+	case p.Metadata.ContentHash != planned.ContentHash:
+		return "inputs changed"
+	}
+	return ""
+}
+
+// apply replaces the target command of the project by the planned one.
+func (planned *PlannedProject) apply(p *Project) {
+	config := *p.Target[p.CMDOptions.Target]
+	config.Exec = &Exec{CMD: planned.Cmd, Params: planned.Params}
+	config.Envs = make([]Env, 0, len(planned.Envs))
+	for _, env := range planned.Envs {
+		key, value, _ := strings.Cut(env, "=")
+		config.Envs = append(config.Envs, Env{Key: key, Value: value})
+	}
+	p.Target[p.CMDOptions.Target] = &config
+	p.plannedReason = planned.Reason
+}
@@ -0,0 +1,84 @@
+package project
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkspacePlan_RecordsResolvedCommandsAndHashes(t *testing.T) {
+	// Arrange
+	root := newWorkspaceRepo(t)
+	w := NewWorkspace(WorkspaceOptions{Root: root, CachePath: t.TempDir()})
+	file := filepath.Join(t.TempDir(), "plan.json")
+
+	// Act
+	plan, err := w.Plan(context.Background(), Options{Target: TargetBuild, NoDaemon: true})
+	assert.NoError(t, err)
+	assert.NoError(t, plan.WriteFile(file))
+	read, err := ReadPlan(file)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, TargetBuild, read.Target)
+	assert.Len(t, read.Projects, 2)
+	api := read.Projects[0]
+	assert.Equal(t, "api", api.Name)
+	assert.Equal(t, "not built yet", api.Reason)
+	assert.Equal(t, []string{"./api/built"}, api.Params)
+	assert.Equal(t, "touch ./api/built", api.Command)
+	assert.NotEmpty(t, api.ContentHash)
+	assert.NoFileExists(t, filepath.Join(root, "api", "built"))
+}
+
+func TestWorkspaceApply_BuildsThePlan(t *testing.T) {
+	// Arrange
+	root := newWorkspaceRepo(t)
+	w := NewWorkspace(WorkspaceOptions{Root: root, CachePath: t.TempDir()})
+	plan, err := w.Plan(context.Background(), Options{Target: TargetBuild, NoDaemon: true})
+	assert.NoError(t, err)
+	plan.Projects = plan.Projects[1:]
+
+	// Act
+	summary, err := w.Apply(context.Background(), plan, Options{NoDaemon: true})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, summary.Results, 1)
+	assert.Equal(t, "worker", summary.Results[0].Name)
+	assert.Equal(t, ResultBuilt, summary.Results[0].Status)
+	assert.Equal(t, "not built yet", summary.Results[0].Reason)
+	assert.FileExists(t, filepath.Join(root, "worker", "built"))
+	assert.NoFileExists(t, filepath.Join(root, "api", "built"))
+}
+
+func TestWorkspaceApply_RefusesOutdatedPlan(t *testing.T) {
+	// Arrange
+	root := newWorkspaceRepo(t)
+	w := NewWorkspace(WorkspaceOptions{Root: root, CachePath: t.TempDir()})
+	plan, err := w.Plan(context.Background(), Options{Target: TargetBuild, NoDaemon: true})
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "worker", "extra.go"), []byte("package main\n"), 0o644))
+
+	// Act
+	summary, err := w.Apply(context.Background(), plan, Options{NoDaemon: true})
+
+	// Assert
+	var outdated *OutdatedPlanError
+	assert.ErrorAs(t, err, &outdated)
+	assert.Equal(t, []string{"worker: files changed"}, outdated.Changes)
+	assert.Nil(t, summary)
+	assert.NoFileExists(t, filepath.Join(root, "api", "built"))
+}
+
+func TestReadPlan_UnsupportedVersion(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "plan.json")
+	assert.NoError(t, os.WriteFile(file, []byte(`{"version": 42, "target": "build"}`), 0o644))
+
+	_, err := ReadPlan(file)
+
+	assert.EqualError(t, err, "unsupported plan version 42, expected 1")
+}
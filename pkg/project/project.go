@@ -1,21 +1,27 @@
 package project
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
 	"slices"
 	"strings"
 	"sync"
+	"time"
 
 	"golang.org/x/mod/modfile"
+	"golang.org/x/sync/errgroup"
 
 	"github.com/kperreau/goac/pkg/hasher"
+	"github.com/kperreau/goac/pkg/logger"
 	"github.com/kperreau/goac/pkg/scan"
+	"github.com/kperreau/goac/pkg/trace"
 	"github.com/kperreau/goac/pkg/utils"
+	"github.com/kperreau/goac/pkg/watch"
 	"gopkg.in/yaml.v3"
-
-	"github.com/kperreau/goac/pkg/printer"
 )
 
 type Env struct {
@@ -29,33 +35,48 @@ type Exec struct {
 }
 
 type TargetConfig struct {
-	Envs []Env `yaml:",omitempty"`
-	Exec *Exec
+	Envs    []Env    `yaml:",omitempty"`
+	Inputs  []string `yaml:",omitempty"`
+	Timeout string   `yaml:",omitempty"`
+	Retries int      `yaml:",omitempty"`
+	Exec    *Exec
 }
 
 type Project struct {
 	Version    string
 	Name       string
-	Path       string `yaml:",omitempty"`
-	CleanPath  string `yaml:",omitempty"`
+	Tags       []string `yaml:",omitempty"`
+	Path       string   `yaml:",omitempty"`
+	CleanPath  string   `yaml:",omitempty"`
 	Target     map[Target]*TargetConfig
-	HashPath   string     `yaml:",omitempty"`
-	Module     *Module    `yaml:",omitempty"`
-	HashPool   *sync.Pool `yaml:",omitempty"`
-	Metadata   *Metadata  `yaml:",omitempty"`
-	Cache      *Cache     `yaml:",omitempty"`
-	Rule       *scan.Rule `yaml:",omitempty"`
-	CMDOptions *Options   `yaml:",omitempty"`
+	HashPath   string       `yaml:",omitempty"`
+	Module     *Module      `yaml:",omitempty"`
+	HashPool   *sync.Pool   `yaml:",omitempty" json:"-"`
+	HashMemo   *hasher.Memo `yaml:",omitempty" json:"-"`
+	Metadata   *Metadata    `yaml:",omitempty"`
+	Cache      *Cache       `yaml:",omitempty" json:"-"`
+	Rule       *scan.Rule   `yaml:",omitempty"`
+	CMDOptions *Options     `yaml:",omitempty" json:"-"`
+	// plannedReason is the reason the project is affected in the plan it is built from.
+	plannedReason string
 }
 
 type IList interface {
 	List()
-	Affected() error
+	Affected(ctx context.Context) error
+	Summary() *Summary
+	AffectedProjects() []*Result
+	Plan() *Plan
+	VerifyCache() error
+	Watch(ctx context.Context, w watch.Watcher, debounce time.Duration) error
 }
 
 type List struct {
 	Projects []*Project
 	Options  *Options
+	summary  *Summary
+	// shardPlan is the distribution of the affected projects when Options.Shard is set
+	shardPlan *shardPlan
 }
 
 type Options struct {
@@ -66,22 +87,153 @@ type Options struct {
 	Force          bool
 	DockerIgnore   bool
 	ProjectsName   []string
-	Debug          []string
-	PrintStdout    bool
+	Tags           []string
+	Paths          []string
+	Exclude        []string
+	// WithDependents and WithDependencies add to the selected projects the projects
+	// importing their packages, and the projects whose packages they import.
+	WithDependents   bool
+	WithDependencies bool
+	Debug            []string
+	PrintStdout      bool
+	HashAlgorithm    hasher.Algorithm
+	SemanticHash     bool
+	KeepGoing        bool
+	// Shard keeps only the affected projects of a shard, PrintShardPlan prints the projects of every shard.
+	Shard          *Shard
+	PrintShardPlan bool
+	// NoDaemon loads the projects without the daemon, even if it runs.
+	NoDaemon bool
+	Tracer   *trace.Tracer
+	// RootPath is the directory of the repository, the project paths are relative to it. RootPath is used if empty.
+	RootPath string
+	// CachePath is the cache directory, relative to RootPath. DefaultCachePath is used if empty.
+	CachePath string
+	// Logger receives the output of the run, logger.Default() is used if nil.
+	Logger Logger
+	// Subscribers receive the lifecycle events of the projects.
+	Subscribers []Subscriber
+	// env is the go env read by the daemon for the projects it loads, goEnv() is used if nil.
+	env map[string]string
+}
+
+// Logger receives the output of goac: Printf the data output, like the listed projects,
+// and the other methods the messages of each level. *logger.Logger is a Logger.
+type Logger interface {
+	Printf(format string, a ...any)
+	Errorf(format string, a ...any)
+	Warnf(format string, a ...any)
+	Infof(format string, a ...any)
+	Debugf(format string, a ...any)
+	Tracef(format string, a ...any)
 }
 
 var RootPath = "."
 
-func NewProjectsList(opt *Options) (IList, error) {
-	projects, err := getProjects(opt)
-	if err != nil {
+// pathHashPool hashes project paths into cache file names, it is always SHA-1
+// so that cache entries are found whatever the configured hash algorithm.
+var pathHashPool = hasher.NewPool()
+
+func (o *Options) expandSelection() bool {
+	return o.WithDependents || o.WithDependencies
+}
+
+func (o *Options) tracer() *trace.Tracer {
+	if o == nil {
+		return nil
+	}
+	return o.Tracer
+}
+
+// span starts a span of the project, in the lane of its path.
+func (p *Project) span(name string) *trace.Span {
+	return p.CMDOptions.tracer().Start(p.CleanPath, name).
+		SetAttribute("project", p.Name).
+		SetAttribute("target", p.CMDOptions.Target.String())
+}
+
+func (o *Options) rootPath() string {
+	if o == nil || o.RootPath == "" {
+		return RootPath
+	}
+	return o.RootPath
+}
+
+// path returns the path of a file of the repository, from the path relative to the root.
+func (o *Options) path(file string) string {
+	return resolvePath(o.rootPath(), file)
+}
+
+func (o *Options) cachePath() string {
+	if o == nil || o.CachePath == "" {
+		return o.path(DefaultCachePath)
+	}
+	return o.path(o.CachePath)
+}
+
+func (o *Options) logger() Logger {
+	if o == nil || o.Logger == nil {
+		return logger.Default()
+	}
+	return o.Logger
+}
+
+// resolvePath joins a path to root, it is kept as is when absolute or when root is the current directory.
+func resolvePath(root string, file string) string {
+	if root == "." || filepath.IsAbs(file) {
+		return file
+	}
+
+	return filepath.Join(root, file)
+}
+
+// relPath returns a path of the repository relative to its root.
+func relPath(root string, file string) (string, error) {
+	if root == "." {
+		return file, nil
+	}
+	return filepath.Rel(root, file)
+}
+
+func (o *Options) hashAlgorithm() hasher.Algorithm {
+	if o == nil || o.HashAlgorithm == "" {
+		return hasher.SHA1
+	}
+	return o.HashAlgorithm
+}
+
+func (o *Options) semanticHash() bool {
+	return o != nil && o.SemanticHash
+}
+
+// NewProjectsList loads the projects selected by opt. If some projects fail to load, the list of
+// the others is returned with LoadErrors.
+func NewProjectsList(ctx context.Context, opt *Options) (IList, error) {
+	start := time.Now()
+	projects, ok, err := loadFromDaemon(ctx, opt)
+	if !ok {
+		projects, err = getProjects(ctx, opt)
+	}
+	var loadErrs LoadErrors
+	if err != nil && !errors.As(err, &loadErrs) {
 		return nil, err
 	}
+	if ok {
+		opt.logger().Debugf("Loaded %d projects from the goac daemon in %s\n", len(projects), time.Since(start).Round(time.Millisecond))
+	} else {
+		opt.logger().Debugf("Loaded %d projects in %s\n", len(projects), time.Since(start).Round(time.Millisecond))
+	}
 
-	return &List{
+	l := &List{
 		Projects: projects,
 		Options:  opt,
-	}, err
+	}
+	if opt.Shard != nil && opt.Target != TargetNone {
+		l.shardPlan = planShards(projects, opt.Shard.Total)
+		l.Projects = l.shardPlan.shards[opt.Shard.Index-1]
+	}
+
+	return l, err
 }
 
 func find(path string, projectFileName string) (files []string, err error) {
@@ -102,23 +254,38 @@ func find(path string, projectFileName string) (files []string, err error) {
 	return files, nil
 }
 
+// findRelative finds the files in root, their paths are relative to root.
+func findRelative(root string, projectFileName string) ([]string, error) {
+	files, err := find(root, projectFileName)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, file := range files {
+		if files[i], err = relPath(root, file); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
 func loadConfig(file string, opts *processProjectOptions) (*Project, error) {
-	data, err := os.ReadFile(file)
+	data, err := os.ReadFile(opts.path(file))
 	if err != nil {
-		return nil, fmt.Errorf("error opening project config: %w", err)
+		return nil, &ConfigError{Path: file, Err: err}
 	}
 
 	var project Project
 	if err = yaml.Unmarshal(data, &project); err != nil {
-		printer.Errorf("failed to unmarshal project config: %s", err.Error())
-		return nil, err
+		return nil, &ConfigError{Path: file, Err: err}
 	}
 	project.CleanPath = utils.CleanPath(file, configFileName)
 	project.Path = utils.AddCurrentDirPrefix(project.CleanPath)
 	project.HashPool = opts.hashPool
+	project.HashMemo = opts.hashMemo
 	project.CMDOptions = opts.Options
 
-	hashPath, err := hasher.WithPool(opts.hashPool, project.CleanPath)
+	hashPath, err := hasher.WithPool(pathHashPool, project.CleanPath)
 	if err != nil {
 		return nil, fmt.Errorf("error hashing files: %w", err)
 	}
@@ -129,113 +296,192 @@ func loadConfig(file string, opts *processProjectOptions) (*Project, error) {
 
 type processProjectOptions struct {
 	*Options
-	gomod     *modfile.File
-	projectCh chan *Project
-	errorsCh  chan error
-	hashPool  *sync.Pool
-	wg        *sync.WaitGroup
-	sem       chan bool
+	gomod    *modfile.File
+	hashPool *sync.Pool
+	hashMemo *hasher.Memo
+	found    *selectables
 }
 
-func getProjects(opt *Options) (projects []*Project, err error) {
+// getProjects loads the projects of the repository, in the order of their paths. The projects are
+// loaded concurrently up to opt.MaxConcurrency and every project is loaded to report all the errors:
+// the loaded projects are returned with LoadErrors. Canceling ctx stops the loading and returns its error.
+func getProjects(ctx context.Context, opt *Options) (projects []*Project, err error) {
 	if opt.MaxConcurrency < 1 {
 		return nil, fmt.Errorf("max concurrency can't be less than 1")
 	}
 
-	projectsFiles, err := find(RootPath, configFileName)
+	selector := opt.selector()
+	if err := selector.Validate(); err != nil {
+		return nil, err
+	}
+
+	span := opt.tracer().Start("goac", "find projects")
+	projectsFiles, err := findRelative(opt.rootPath(), configFileName)
+	span.End()
 	if err != nil {
 		return nil, err
 	}
 
 	// preload go mod file dependencies
-	gomod, err := loadGOModFile(RootPath)
+	gomod, err := loadGOModFile(opt.rootPath())
+	if err != nil {
+		return nil, err
+	}
+	gomod, err = loadVendorModules(opt, gomod)
 	if err != nil {
 		return nil, err
 	}
 
-	// init process options
-	sem := make(chan bool, opt.MaxConcurrency)
-	projectsCh := make(chan *Project)
-	errorsCh := make(chan error)
-	wg := sync.WaitGroup{}
+	// init process options, file hashes are shared by all projects of the run
+	hashPool := hasher.NewAlgorithmPool(opt.hashAlgorithm())
 	pOpts := &processProjectOptions{
-		Options:   opt,
-		projectCh: projectsCh,
-		errorsCh:  errorsCh,
-		hashPool:  hasher.NewPool(),
-		wg:        &wg,
-		sem:       sem,
-		gomod:     gomod,
-	}
-
-	for _, projectFile := range projectsFiles {
-		sem <- true // acquire
-		wg.Add(1)
-		go processProject(pOpts, projectFile)
-	}
-
-	wg.Wait()
-	for i := 0; i < len(projectsFiles); i++ {
-		select {
-		case project := <-projectsCh:
-			if project != nil {
-				projects = append(projects, project)
+		Options:  opt,
+		hashPool: hashPool,
+		hashMemo: hasher.NewMemo(hashPool, runtime.NumCPU()).Semantic(opt.SemanticHash).Root(opt.rootPath()),
+		found:    &selectables{},
+		gomod:    gomod,
+	}
+
+	// each project has its own slot, so the results keep the order of the files whatever the order they load in
+	loaded := make([]*Project, len(projectsFiles))
+	errs := make([]error, len(projectsFiles))
+	// the errors of the projects are kept in errs, only the cancellation of ctx stops the group
+	eg, egCtx := errgroup.WithContext(ctx)
+	eg.SetLimit(opt.MaxConcurrency)
+	for i, projectFile := range projectsFiles {
+		if egCtx.Err() != nil {
+			break
+		}
+		eg.Go(func() error {
+			if err := egCtx.Err(); err != nil {
+				return err
 			}
-		case err := <-errorsCh:
-			return nil, err
+			loaded[i], errs[i] = processProject(egCtx, pOpts, projectFile)
+			return nil
+		})
+	}
+	_ = eg.Wait()
+
+	for _, project := range loaded {
+		if project != nil {
+			projects = append(projects, project)
 		}
 	}
+	// the errors of the projects interrupted by the cancellation are replaced by its cause
+	if err := ctx.Err(); err != nil {
+		return projects, err
+	}
+	// the projects that loaded are returned with the errors of the others
+	errs = slices.DeleteFunc(errs, func(err error) bool { return err == nil })
+	if len(errs) == 0 {
+		selector.warnUnmatched(opt.logger(), pOpts.found.projects)
+	}
+
+	if opt.expandSelection() {
+		var graphErrs []error
+		projects, graphErrs = selectWithGraph(opt, projects)
+		errs = append(errs, graphErrs...)
+	}
+	if len(errs) > 0 {
+		return projects, newLoadErrors(errs)
+	}
 
 	return projects, nil
 }
 
-func processProject(opt *processProjectOptions, projectFile string) {
-	defer opt.wg.Done()
-	defer func() {
-		<-opt.sem // release
-	}()
+// selectWithGraph selects the projects with their dependents and/or dependencies,
+// then loads their target data. All the projects are loaded up to their modules to build the graph.
+// It returns the projects that loaded and the errors of the others.
+func selectWithGraph(opt *Options, all []*Project) ([]*Project, []error) {
+	var selected []*Project
+	for _, p := range all {
+		if opt.selector().Match(p.Name, p.CleanPath, p.Tags) {
+			selected = append(selected, p)
+		}
+	}
+
+	projects := newProjectGraph(all).expand(all, selected, opt.WithDependents, opt.WithDependencies)
+	if opt.Target == TargetNone {
+		return projects, nil
+	}
+
+	// every project is loaded to report all the errors
+	errs := make([]error, len(projects))
+	eg := errgroup.Group{}
+	eg.SetLimit(opt.MaxConcurrency)
+	for i, p := range projects {
+		eg.Go(func() error {
+			errs[i] = p.loadTargetData()
+			return nil
+		})
+	}
+	_ = eg.Wait()
 
+	loaded := make([]*Project, 0, len(projects))
+	for i, p := range projects {
+		if errs[i] == nil {
+			loaded = append(loaded, p)
+		}
+	}
+	return loaded, slices.DeleteFunc(errs, func(err error) bool { return err == nil })
+}
+
+// processProject loads a project from its config file, the project is nil if it is not selected.
+func processProject(ctx context.Context, opt *processProjectOptions, projectFile string) (*Project, error) {
 	// load config file .goacproject.yaml
+	span := opt.tracer().Start(utils.CleanPath(projectFile, configFileName), "load config")
 	project, err := loadConfig(projectFile, opt)
+	span.End()
 	if err != nil {
-		go func() { opt.errorsCh <- fmt.Errorf("error loading config: %w", err) }()
-		return
+		return nil, err
 	}
 
-	// Skip if option cli cmd --projects is set and not match this project name
-	if len(opt.Options.ProjectsName) > 0 && !slices.Contains(opt.Options.ProjectsName, project.Name) {
-		go func() { opt.projectCh <- nil }()
-		return
+	// Skip if the project is not selected by the cli cmd --projects, --tags, --paths and --exclude,
+	// the selection is done once every project is loaded when dependents or dependencies are selected too
+	opt.found.add(project)
+	if !opt.Options.expandSelection() && !opt.Options.selector().Match(project.Name, project.CleanPath, project.Tags) {
+		return nil, nil
 	}
 
 	// load go modules with go list cmd cli (list imports and dependencies)
-	if err := project.LoadGOModules(opt.gomod); err != nil {
-		go func() { opt.errorsCh <- fmt.Errorf("error loading modules: %w", err) }()
-		return
+	span = project.span("load modules")
+	err = project.LoadGOModules(ctx, opt.gomod)
+	span.End()
+	if err != nil {
+		return nil, err
 	}
 
+	project.emit(Event{Type: EventProjectLoaded})
+
 	// no need affected data, return project (list for example)
-	if opt.Target == TargetNone {
-		go func() { opt.projectCh <- project }()
-		return
+	if opt.Target == TargetNone || opt.Options.expandSelection() {
+		return project, nil
+	}
+
+	if err := project.loadTargetData(); err != nil {
+		return nil, err
 	}
 
+	return project, nil
+}
+
+// loadTargetData loads the cache, the rule and the hashes of the project for the target.
+func (p *Project) loadTargetData() error {
 	// load caches data
-	if err := project.LoadCache(); err != nil {
-		go func() { opt.errorsCh <- err }()
-		return
+	span := p.span("load cache")
+	err := p.LoadCache()
+	span.End()
+	if err != nil {
+		return err
 	}
 
-	if opt.DockerIgnore {
+	if p.CMDOptions.DockerIgnore {
 		// load includes/excludes rule
-		project.LoadRule(opt.Target)
+		p.LoadRule(p.CMDOptions.Target)
 	}
 
 	// load hashs
-	if err := project.LoadHashs(); err != nil {
-		go func() { opt.errorsCh <- err }()
-		return
-	}
-
-	go func() { opt.projectCh <- project }()
+	span = p.span("load hashes")
+	defer span.End()
+	return p.LoadHashs()
 }
@@ -1,11 +1,15 @@
 package project
 
 import (
+	"bytes"
+	"context"
 	"crypto/sha1"
+	"os"
 	"path/filepath"
 	"sync"
 	"testing"
 
+	"github.com/kperreau/goac/pkg/logger"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -27,7 +31,7 @@ func TestNewProjectsList_ValidOptions(t *testing.T) {
 	RootPath = "./../.."
 	defer func() { RootPath = OldRootPath }()
 
-	list, err := NewProjectsList(opt)
+	list, err := NewProjectsList(context.Background(), opt)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, list)
@@ -51,7 +55,7 @@ func TestNewProjectsList_InvalidPath(t *testing.T) {
 	RootPath = "invalid-path"
 	defer func() { RootPath = OldRootPath }()
 
-	list, err := NewProjectsList(opt)
+	list, err := NewProjectsList(context.Background(), opt)
 
 	assert.Error(t, err)
 	assert.Nil(t, list)
@@ -177,7 +181,7 @@ func TestGetProjects_ValidOptionsAndProjectFilesFound(t *testing.T) {
 	RootPath = "../.."
 	defer func() { RootPath = OldRootPath }()
 
-	projects, err := getProjects(opt)
+	projects, err := getProjects(context.Background(), opt)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, projects)
@@ -197,7 +201,7 @@ func TestGetProjects_NoProjectFilesFound(t *testing.T) {
 		PrintStdout:    false,
 	}
 
-	projects, err := getProjects(opt)
+	projects, err := getProjects(context.Background(), opt)
 
 	assert.Error(t, err)
 	assert.Nil(t, projects)
@@ -205,11 +209,7 @@ func TestGetProjects_NoProjectFilesFound(t *testing.T) {
 
 func TestProcessProject_ProjectFound(t *testing.T) {
 	// Arrange
-	sem := make(chan bool, 2)
-	projectsCh := make(chan *Project)
-	errorsCh := make(chan error)
 	projectFile := filepath.Join("../..", configFileName)
-	wg := sync.WaitGroup{}
 	mfile, err := loadGOModFile("./../..")
 	assert.NoError(t, err)
 	opts := &processProjectOptions{
@@ -219,39 +219,22 @@ func TestProcessProject_ProjectFound(t *testing.T) {
 		Options: &Options{
 			Target: TargetNone,
 		},
-		sem:       sem,
-		projectCh: projectsCh,
-		errorsCh:  errorsCh,
-		wg:        &wg,
-		gomod:     mfile,
+		found: &selectables{},
+		gomod: mfile,
 	}
 
 	// Act
-	sem <- true // acquire
-	wg.Add(1)
-	go processProject(opts, projectFile)
-	wg.Wait()
-
-	var errorsProjects error
-	var project *Project
-	select {
-	case project = <-projectsCh:
-	case errorsProjects = <-errorsCh:
-	}
+	project, err := processProject(context.Background(), opts, projectFile)
 
 	// Assert
-	assert.NoError(t, errorsProjects)
+	assert.NoError(t, err)
 	assert.NotEmpty(t, project)
 	assert.Equal(t, "goac", project.Name)
 }
 
 func TestProcessProject_SkipProjectIfProjectsOptionNotMatch(t *testing.T) {
 	// Arrange
-	sem := make(chan bool, 3)
-	projectsCh := make(chan *Project)
-	errorsCh := make(chan error)
 	projectFile := filepath.Join("../..", configFileName)
-	wg := sync.WaitGroup{}
 	opts := &processProjectOptions{
 		hashPool: &sync.Pool{
 			New: func() any { return sha1.New() },
@@ -259,37 +242,20 @@ func TestProcessProject_SkipProjectIfProjectsOptionNotMatch(t *testing.T) {
 		Options: &Options{
 			ProjectsName: []string{"project1", "project2"},
 		},
-		sem:       sem,
-		projectCh: projectsCh,
-		errorsCh:  errorsCh,
-		wg:        &wg,
+		found: &selectables{},
 	}
 
 	// Act
-	sem <- true // acquire
-	wg.Add(1)
-	go processProject(opts, projectFile)
-	wg.Wait()
-
-	var errorsProjects error
-	var project *Project
-	select {
-	case project = <-projectsCh:
-	case errorsProjects = <-errorsCh:
-	}
+	project, err := processProject(context.Background(), opts, projectFile)
 
 	// Assert
 	assert.Empty(t, project)
-	assert.NoError(t, errorsProjects)
+	assert.NoError(t, err)
 }
 
 func TestProcessProject_NoProjectFilesFound(t *testing.T) {
 	// Arrange
-	sem := make(chan bool, 3)
-	projectsCh := make(chan *Project)
-	errorsCh := make(chan error)
 	projectFile := filepath.Join("invalid-path", configFileName)
-	wg := sync.WaitGroup{}
 	opts := &processProjectOptions{
 		hashPool: &sync.Pool{
 			New: func() any { return sha1.New() },
@@ -297,26 +263,100 @@ func TestProcessProject_NoProjectFilesFound(t *testing.T) {
 		Options: &Options{
 			ProjectsName: []string{"goac"},
 		},
-		sem:       sem,
-		projectCh: projectsCh,
-		errorsCh:  errorsCh,
-		wg:        &wg,
+		found: &selectables{},
 	}
 
 	// Act
-	sem <- true // acquire
-	wg.Add(1)
-	go processProject(opts, projectFile)
-	wg.Wait()
-
-	var err error
-	var project *Project
-	select {
-	case project = <-projectsCh:
-	case err = <-errorsCh:
-	}
+	project, err := processProject(context.Background(), opts, projectFile)
 
 	// Assert
 	assert.Empty(t, project)
 	assert.Error(t, err)
 }
+
+func TestGetProjects_DeterministicOrder(t *testing.T) {
+	// Arrange
+	root := newWorkspaceRepo(t)
+	for _, name := range []string{"c", "a", "b"} {
+		assert.NoError(t, os.MkdirAll(filepath.Join(root, "svc", name), 0o755))
+		assert.NoError(t, os.WriteFile(filepath.Join(root, "svc", name, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0o644))
+		assert.NoError(t, os.WriteFile(filepath.Join(root, "svc", name, configFileName), []byte("version: 1.0\nname: "+name+"\n"), 0o644))
+	}
+	opt := &Options{Target: TargetNone, MaxConcurrency: 5, RootPath: root, Logger: logger.Discard()}
+
+	for i := 0; i < 3; i++ {
+		// Act
+		projects, err := getProjects(context.Background(), opt)
+
+		// Assert
+		assert.NoError(t, err)
+		var paths []string
+		for _, p := range projects {
+			paths = append(paths, p.CleanPath)
+		}
+		assert.Equal(t, []string{"api", "svc/a", "svc/b", "svc/c", "worker"}, paths)
+	}
+}
+
+func TestGetProjects_ReturnsLoadedProjectsWithErrors(t *testing.T) {
+	// Arrange
+	root := newWorkspaceRepo(t)
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "api", configFileName), []byte("name: [\n"), 0o644))
+
+	// Act
+	projects, err := getProjects(context.Background(), &Options{Target: TargetNone, MaxConcurrency: 2, RootPath: root, Logger: logger.Discard()})
+
+	// Assert
+	var loadErrs LoadErrors
+	assert.ErrorAs(t, err, &loadErrs)
+	assert.Len(t, loadErrs, 1)
+	assert.Len(t, projects, 1)
+	assert.Equal(t, "worker", projects[0].Name)
+}
+
+func TestGetProjects_CanceledContext(t *testing.T) {
+	// Arrange
+	root := newWorkspaceRepo(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Act
+	projects, err := getProjects(ctx, &Options{Target: TargetNone, MaxConcurrency: 1, RootPath: root, Logger: logger.Discard()})
+
+	// Assert
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, projects)
+}
+
+func TestGetProjects_WarnsUnmatchedSelector(t *testing.T) {
+	// Arrange
+	var stdout, stderr bytes.Buffer
+	opt := &Options{
+		Target:         TargetNone,
+		MaxConcurrency: 2,
+		ProjectsName:   []string{"goca"},
+		Logger:         newTestLogger(logger.LevelInfo, &stdout, &stderr),
+	}
+
+	OldRootPath := RootPath
+	RootPath = "../.."
+	defer func() { RootPath = OldRootPath }()
+
+	// Act
+	projects, err := getProjects(context.Background(), opt)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Empty(t, projects)
+	assert.Empty(t, stdout.String())
+	assert.Contains(t, stderr.String(), "no project matches \"goca\", did you mean goac?")
+}
+
+func TestGetProjects_InvalidSelector(t *testing.T) {
+	opt := &Options{Target: TargetNone, MaxConcurrency: 2, ProjectsName: []string{"/(/"}}
+
+	projects, err := getProjects(context.Background(), opt)
+
+	assert.Error(t, err)
+	assert.Nil(t, projects)
+}
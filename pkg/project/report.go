@@ -0,0 +1,196 @@
+package project
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+type ReportFormat string
+
+const (
+	ReportJSON     ReportFormat = "json"
+	ReportJUnit    ReportFormat = "junit"
+	ReportMarkdown ReportFormat = "markdown"
+)
+
+var ReportFormats = []ReportFormat{ReportJSON, ReportJUnit, ReportMarkdown}
+
+// Report is a report of a run written to a file.
+type Report struct {
+	Format ReportFormat
+	Path   string
+}
+
+// ParseReport parses a report written as format=path, e.g. junit=report.xml.
+func ParseReport(s string) (*Report, error) {
+	format, path, found := strings.Cut(s, "=")
+	if !found || path == "" {
+		return nil, fmt.Errorf("invalid report %q, expected format=path", s)
+	}
+
+	for _, f := range ReportFormats {
+		if string(f) == format {
+			return &Report{Format: f, Path: path}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("invalid report format %q, valid formats are: json,junit,markdown", format)
+}
+
+// WriteReport writes the summary to the report file.
+func (s *Summary) WriteReport(r *Report) error {
+	switch r.Format {
+	case ReportJSON:
+		return s.WriteJSON(r.Path)
+	case ReportJUnit:
+		data, err := s.JUnit()
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(r.Path, data, 0o644)
+	case ReportMarkdown:
+		return os.WriteFile(r.Path, []byte(s.Markdown()), 0o644)
+	}
+
+	return fmt.Errorf("invalid report format %q", r.Format)
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+	SystemErr string        `xml:"system-err,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// JUnit returns the summary as a JUnit XML report, each project is a test case of the target suite.
+// Cached and skipped projects are reported as skipped test cases.
+func (s *Summary) JUnit() ([]byte, error) {
+	suite := junitTestSuite{
+		Name: fmt.Sprintf("goac %s", s.Target),
+		Time: junitTime(s.Duration),
+	}
+
+	for _, r := range s.Results {
+		testCase := junitTestCase{
+			Name:      r.Name,
+			ClassName: s.Target.String(),
+			Time:      junitTime(r.Duration),
+		}
+
+		switch r.Status {
+		case ResultFailed:
+			testCase.Failure = &junitMessage{Message: errorExcerpt(r.Error), Text: r.Error}
+			testCase.SystemOut, testCase.SystemErr = r.Stdout, r.Stderr
+			suite.Failures++
+		case ResultCached, ResultSkipped:
+			testCase.Skipped = &junitMessage{Message: string(r.Status)}
+			suite.Skipped++
+		}
+
+		suite.Cases = append(suite.Cases, testCase)
+	}
+	suite.Tests = len(suite.Cases)
+
+	data, err := xml.MarshalIndent(junitTestSuites{
+		Name:     "goac",
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Skipped:  suite.Skipped,
+		Time:     suite.Time,
+		Suites:   []junitTestSuite{suite},
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
+
+func junitTime(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+// Markdown returns the summary as a Markdown report listing the affected projects
+// with the reason they were affected, and the errors of the failed ones.
+func (s *Summary) Markdown() string {
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "### GOAC %s\n\n", s.Target)
+	fmt.Fprintf(&b, "%d built, %d cached, %d skipped, %d failed in %s\n\n",
+		s.Count(ResultBuilt), s.Count(ResultCached), s.Count(ResultSkipped), s.Count(ResultFailed),
+		s.Duration.Round(time.Millisecond))
+
+	affected := 0
+	for _, r := range s.Results {
+		if r.Reason == "" {
+			continue
+		}
+		if affected == 0 {
+			b.WriteString("| Project | Path | Status | Reason | Duration |\n")
+			b.WriteString("|:--|:--|:--|:--|--:|\n")
+		}
+		affected++
+		fmt.Fprintf(&b, "| %s | `%s` | %s %s | %s | %s |\n", markdownEscape(r.Name), r.Path,
+			markdownStatusIcon(r.Status), r.Status, r.Reason, r.Duration.Round(time.Millisecond))
+	}
+	if affected == 0 {
+		b.WriteString("No affected projects.\n")
+	}
+
+	for _, r := range s.Results {
+		if r.Status != ResultFailed {
+			continue
+		}
+		fmt.Fprintf(&b, "\n<details><summary>%s failed</summary>\n\n```\n%s\n```\n</details>\n",
+			markdownEscape(r.Name), strings.TrimSpace(r.Error+"\n"+r.Stdout))
+	}
+
+	return b.String()
+}
+
+func markdownStatusIcon(s ResultStatus) string {
+	switch s {
+	case ResultBuilt, ResultAffected:
+		return "✅"
+	case ResultFailed:
+		return "❌"
+	case ResultSkipped:
+		return "⏭️"
+	}
+	return "💤"
+}
+
+func markdownEscape(s string) string {
+	return strings.NewReplacer("|", `\|`, "<", "&lt;", ">", "&gt;").Replace(s)
+}
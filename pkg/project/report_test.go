@@ -0,0 +1,92 @@
+package project
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newReportSummary() *Summary {
+	return &Summary{
+		Target:   TargetBuild,
+		Duration: 3 * time.Second,
+		Results: []*Result{
+			{Name: "auth", Path: "services/auth", Status: ResultBuilt, Reason: "files changed", Duration: time.Second},
+			{Name: "user", Path: "services/user", Status: ResultCached},
+			{
+				Name: "docs", Path: "docs", Status: ResultFailed, Reason: "not built yet", Duration: 2 * time.Second,
+				Error: "error building docs: make docs: exit status 1: missing file", Stdout: "step 1", Stderr: "missing file",
+			},
+		},
+	}
+}
+
+func TestParseReport(t *testing.T) {
+	r, err := ParseReport("junit=out/report.xml")
+
+	assert.NoError(t, err)
+	assert.Equal(t, &Report{Format: ReportJUnit, Path: "out/report.xml"}, r)
+}
+
+func TestParseReport_Invalid(t *testing.T) {
+	for _, s := range []string{"junit", "junit=", "html=report.html"} {
+		_, err := ParseReport(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestSummaryJUnit(t *testing.T) {
+	// Act
+	data, err := newReportSummary().JUnit()
+
+	// Assert
+	assert.NoError(t, err)
+
+	var suites junitTestSuites
+	assert.NoError(t, xml.Unmarshal(data, &suites))
+	assert.Equal(t, 3, suites.Tests)
+	assert.Equal(t, 1, suites.Failures)
+	assert.Equal(t, 1, suites.Skipped)
+
+	cases := suites.Suites[0].Cases
+	assert.Equal(t, "auth", cases[0].Name)
+	assert.Equal(t, "1.000", cases[0].Time)
+	assert.Nil(t, cases[0].Failure)
+	assert.Equal(t, "cached", cases[1].Skipped.Message)
+	assert.Equal(t, "error building docs: make docs: exit status 1: missing file", cases[2].Failure.Text)
+	assert.Equal(t, "step 1", cases[2].SystemOut)
+	assert.Equal(t, "missing file", cases[2].SystemErr)
+}
+
+func TestSummaryMarkdown(t *testing.T) {
+	// Act
+	md := newReportSummary().Markdown()
+
+	// Assert
+	assert.Contains(t, md, "1 built, 1 cached, 0 skipped, 1 failed in 3s")
+	assert.Contains(t, md, "| auth | `services/auth` | ✅ built | files changed | 1s |\n")
+	assert.Contains(t, md, "| docs | `docs` | ❌ failed | not built yet | 2s |\n")
+	assert.NotContains(t, md, "| user |")
+	assert.Contains(t, md, "<details><summary>docs failed</summary>")
+}
+
+func TestSummaryMarkdown_NoAffectedProjects(t *testing.T) {
+	s := &Summary{Target: TargetBuild, Results: []*Result{{Name: "user", Status: ResultCached}}}
+
+	assert.Contains(t, s.Markdown(), "No affected projects.\n")
+}
+
+func TestSummaryWriteReport(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "report.md")
+
+	err := newReportSummary().WriteReport(&Report{Format: ReportMarkdown, Path: file})
+
+	assert.NoError(t, err)
+	assert.FileExists(t, file)
+	data, _ := os.ReadFile(file)
+	assert.Contains(t, string(data), "### GOAC build")
+}
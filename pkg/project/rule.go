@@ -3,6 +3,8 @@ package project
 import (
 	"fmt"
 	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/codeskyblue/dockerignore"
 	"github.com/kperreau/goac/pkg/scan"
@@ -19,6 +21,73 @@ var DefaultFilesToExclude = map[Target][]string{
 	TargetBuildImage: {".goacproject.yaml", "*_test.go"},
 }
 
+// DefaultInputs are the directories walked for non-go files when a target declares no inputs.
+var DefaultInputs = map[Target][]string{
+	TargetBuild:      {},
+	TargetBuildImage: {"{{project-path}}"},
+}
+
+// inputs returns the directories declared as inputs of the target, or the default ones.
+func (p *Project) inputs() (dirs []string) {
+	inputs := DefaultInputs[p.CMDOptions.Target]
+	if config := p.Target[p.CMDOptions.Target]; config != nil && config.Inputs != nil {
+		inputs = config.Inputs
+	}
+
+	replacer := strings.NewReplacer("{{project-name}}", p.Name, "{{project-path}}", p.CleanPath)
+	for _, input := range inputs {
+		dirs = append(dirs, filepath.Clean(replacer.Replace(input)))
+	}
+	return dirs
+}
+
+// inputRule is the rule of the declared inputs, they are walked for non-go files only.
+func (p *Project) inputRule() *scan.Rule {
+	var excludes []string
+	if p.Rule != nil {
+		excludes = slices.Clone(p.Rule.Excludes)
+	}
+	return &scan.Rule{Excludes: append(excludes, "*.go")}
+}
+
+// scanInputs returns the files of an input directory matching the rule, relative to the root.
+func (p *Project) scanInputs(dir string, rule *scan.Rule) ([]string, error) {
+	root := p.CMDOptions.rootPath()
+	files, err := scan.Dirs([]string{resolvePath(root, dir)}, rule)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, file := range files {
+		rel, err := relPath(root, file)
+		if err != nil {
+			return nil, err
+		}
+		files[i] = filepath.ToSlash(rel)
+	}
+	return files, nil
+}
+
+// inputDirs returns the directory of an input and its subdirectories not excluded by the rule,
+// relative to the root like the files. It returns the parent directory of a file input.
+func (p *Project) inputDirs(input string, rule *scan.Rule) ([]string, error) {
+	root := p.CMDOptions.rootPath()
+	dirs, err := scan.SubDirs(resolvePath(root, input), rule)
+	if err != nil {
+		return nil, err
+	}
+	if len(dirs) == 0 {
+		return []string{filepath.Dir(input)}, nil
+	}
+
+	for i, dir := range dirs {
+		if dirs[i], err = relPath(root, dir); err != nil {
+			return nil, err
+		}
+	}
+	return dirs, nil
+}
+
 func (p *Project) LoadRule(target Target) {
 	p.Rule = &scan.Rule{
 		Includes: DefaultFilesToInclude[target],
@@ -26,7 +95,7 @@ func (p *Project) LoadRule(target Target) {
 	}
 
 	// add .dockerignore entries to the exclude files rules
-	dockerIgnoreFiles, err := dockerignore.ReadIgnoreFile(filepath.Clean(fmt.Sprintf("%s/.dockerignore", p.CleanPath)))
+	dockerIgnoreFiles, err := dockerignore.ReadIgnoreFile(p.CMDOptions.path(filepath.Clean(fmt.Sprintf("%s/.dockerignore", p.CleanPath))))
 	if err == nil {
 		p.Rule.Excludes = utils.AppendIfNotExist(p.Rule.Excludes, dockerIgnoreFiles...)
 	}
@@ -52,3 +52,29 @@ func TestLoadRule_HandlesEmptyIgnoredGoFiles(t *testing.T) {
 	// Assert that the exclude list only contains the default excludes
 	assert.Equal(t, DefaultFilesToExclude[TargetBuild], p.Rule.Excludes)
 }
+
+func TestInputs_DefaultInputs(t *testing.T) {
+	p := &Project{
+		Name:       "goac",
+		CleanPath:  "services/goac",
+		CMDOptions: &Options{Target: TargetBuildImage},
+	}
+
+	assert.Equal(t, []string{"services/goac"}, p.inputs())
+
+	p.CMDOptions.Target = TargetBuild
+	assert.Empty(t, p.inputs())
+}
+
+func TestInputs_DeclaredInputs(t *testing.T) {
+	p := &Project{
+		Name:      "goac",
+		CleanPath: "services/goac",
+		Target: map[Target]*TargetConfig{
+			TargetBuild: {Inputs: []string{"{{project-path}}/assets", "configs/{{project-name}}/"}},
+		},
+		CMDOptions: &Options{Target: TargetBuild},
+	}
+
+	assert.Equal(t, []string{"services/goac/assets", "configs/goac"}, p.inputs())
+}
@@ -0,0 +1,204 @@
+package project
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Selector selects projects by name, tag and path, an empty selector selects every project.
+// A project is selected if it matches one of the names, one of the tags and one of the paths
+// of the selector, and none of the excludes.
+//
+// Names and excludes are exact names, glob patterns like "auth-*", or regular expressions
+// between slashes like "/^(auth|user)$/". Paths are path prefixes like "services/auth".
+type Selector struct {
+	Names   []string
+	Tags    []string
+	Paths   []string
+	Exclude []string
+}
+
+func (o *Options) selector() *Selector {
+	return &Selector{Names: o.ProjectsName, Tags: o.Tags, Paths: o.Paths, Exclude: o.Exclude}
+}
+
+// Validate checks the syntax of the name patterns.
+func (s *Selector) Validate() error {
+	for _, pattern := range append(slices.Clone(s.Names), s.Exclude...) {
+		if _, err := matchName(pattern, ""); err != nil {
+			return fmt.Errorf("invalid project pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// Match reports if a project is selected.
+func (s *Selector) Match(name string, projectPath string, tags []string) bool {
+	if s == nil {
+		return true
+	}
+
+	if len(s.Names) > 0 && !slices.ContainsFunc(s.Names, func(pattern string) bool { return isNameMatch(pattern, name) }) {
+		return false
+	}
+	if len(s.Tags) > 0 && !slices.ContainsFunc(s.Tags, func(tag string) bool { return slices.Contains(tags, tag) }) {
+		return false
+	}
+	if len(s.Paths) > 0 && !slices.ContainsFunc(s.Paths, func(prefix string) bool { return isPathMatch(prefix, projectPath) }) {
+		return false
+	}
+
+	return !slices.ContainsFunc(s.Exclude, func(pattern string) bool { return isNameMatch(pattern, name) })
+}
+
+func isNameMatch(pattern string, name string) bool {
+	ok, _ := matchName(pattern, name)
+	return ok
+}
+
+func matchName(pattern string, name string) (bool, error) {
+	if len(pattern) > 1 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		re, err := regexp.Compile(pattern[1 : len(pattern)-1])
+		if err != nil {
+			return false, err
+		}
+		return re.MatchString(name), nil
+	}
+
+	if strings.ContainsAny(pattern, "*?[") {
+		return path.Match(pattern, name)
+	}
+
+	return pattern == name, nil
+}
+
+func isPathMatch(prefix string, projectPath string) bool {
+	prefix = path.Clean(strings.TrimPrefix(prefix, "./"))
+	projectPath = path.Clean(projectPath)
+	return prefix == "." || projectPath == prefix || strings.HasPrefix(projectPath, prefix+"/")
+}
+
+// selectable is a project known by the selector, selected or not.
+type selectable struct {
+	name string
+	path string
+	tags []string
+}
+
+// selectables records the projects found during a run, selected or not.
+type selectables struct {
+	mu       sync.Mutex
+	projects []selectable
+}
+
+func (s *selectables) add(p *Project) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.projects = append(s.projects, selectable{name: p.Name, path: p.CleanPath, tags: p.Tags})
+}
+
+// warnUnmatched warns about each name, tag, path and exclude of the selector matching none of the projects,
+// with the closest names, tags or paths as suggestions.
+func (s *Selector) warnUnmatched(log Logger, projects []selectable) {
+	var names, tags, paths []string
+	for _, p := range projects {
+		names = append(names, p.name)
+		tags = append(tags, p.tags...)
+		paths = append(paths, p.path)
+	}
+
+	for _, pattern := range s.Names {
+		if !slices.ContainsFunc(names, func(name string) bool { return isNameMatch(pattern, name) }) {
+			log.Warnf("no project matches %q%s\n", pattern, suggest(pattern, names))
+		}
+	}
+	for _, tag := range s.Tags {
+		if !slices.Contains(tags, tag) {
+			log.Warnf("no project has tag %q%s\n", tag, suggest(tag, tags))
+		}
+	}
+	for _, prefix := range s.Paths {
+		if !slices.ContainsFunc(paths, func(p string) bool { return isPathMatch(prefix, p) }) {
+			log.Warnf("no project in path %q%s\n", prefix, suggest(prefix, paths))
+		}
+	}
+	for _, pattern := range s.Exclude {
+		if !slices.ContainsFunc(names, func(name string) bool { return isNameMatch(pattern, name) }) {
+			log.Warnf("no project to exclude matches %q%s\n", pattern, suggest(pattern, names))
+		}
+	}
+}
+
+// maxSuggestions is the max number of close matches suggested for a selector matching nothing.
+const maxSuggestions = 3
+
+// suggest returns the values close to s, as a message to append to a warning.
+func suggest(s string, values []string) string {
+	type candidate struct {
+		value    string
+		distance int
+	}
+
+	var candidates []candidate
+	seen := map[string]bool{}
+	for _, value := range values {
+		if seen[value] {
+			continue
+		}
+		seen[value] = true
+
+		distance := levenshtein(strings.ToLower(s), strings.ToLower(value))
+		if distance <= max(2, len(s)/3) || strings.Contains(value, s) {
+			candidates = append(candidates, candidate{value: value, distance: distance})
+		}
+	}
+	if len(candidates) == 0 {
+		return ""
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].distance != candidates[j].distance {
+			return candidates[i].distance < candidates[j].distance
+		}
+		return candidates[i].value < candidates[j].value
+	})
+
+	suggestions := make([]string, 0, maxSuggestions)
+	for _, c := range candidates[:min(len(candidates), maxSuggestions)] {
+		suggestions = append(suggestions, c.value)
+	}
+	return fmt.Sprintf(", did you mean %s?", strings.Join(suggestions, ", "))
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a string, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
@@ -0,0 +1,74 @@
+package project
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/kperreau/goac/pkg/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectorMatch_Empty(t *testing.T) {
+	assert.True(t, (&Selector{}).Match("auth", "services/auth", nil))
+}
+
+func TestSelectorMatch_Names(t *testing.T) {
+	s := &Selector{Names: []string{"auth", "user-*", "/^billing-(api|worker)$/"}}
+
+	assert.True(t, s.Match("auth", "services/auth", nil))
+	assert.True(t, s.Match("user-api", "services/user", nil))
+	assert.True(t, s.Match("billing-worker", "services/billing", nil))
+	assert.False(t, s.Match("authz", "services/authz", nil))
+	assert.False(t, s.Match("billing-cron", "services/billing", nil))
+}
+
+func TestSelectorMatch_TagsAndPaths(t *testing.T) {
+	s := &Selector{Tags: []string{"backend"}, Paths: []string{"./services"}}
+
+	assert.True(t, s.Match("auth", "services/auth", []string{"go", "backend"}))
+	assert.False(t, s.Match("auth", "services/auth", []string{"go"}))
+	assert.False(t, s.Match("docs", "services-legacy/docs", []string{"backend"}))
+}
+
+func TestSelectorMatch_Exclude(t *testing.T) {
+	s := &Selector{Paths: []string{"services"}, Exclude: []string{"*-legacy"}}
+
+	assert.True(t, s.Match("auth", "services/auth", nil))
+	assert.False(t, s.Match("auth-legacy", "services/auth-legacy", nil))
+}
+
+func TestSelectorValidate_InvalidRegexp(t *testing.T) {
+	assert.Error(t, (&Selector{Names: []string{"/(/"}}).Validate())
+	assert.Error(t, (&Selector{Exclude: []string{"[a"}}).Validate())
+	assert.NoError(t, (&Selector{Names: []string{"auth", "a*", "/^a/"}}).Validate())
+}
+
+func TestSelectorWarnUnmatched_SuggestsCloseMatches(t *testing.T) {
+	// Arrange
+	var stdout, stderr bytes.Buffer
+	s := &Selector{Names: []string{"auht", "auth"}, Tags: []string{"backnd"}, Paths: []string{"service"}, Exclude: []string{"usr", "auth"}}
+	projects := []selectable{
+		{name: "auth", path: "services/auth", tags: []string{"backend"}},
+		{name: "user", path: "services/user"},
+	}
+
+	// Act
+	s.warnUnmatched(newTestLogger(logger.LevelInfo, &stdout, &stderr), projects)
+
+	// Assert
+	assert.Equal(t, "no project matches \"auht\", did you mean auth?\n"+
+		"no project has tag \"backnd\", did you mean backend?\n"+
+		"no project in path \"service\", did you mean services/auth, services/user?\n"+
+		"no project to exclude matches \"usr\", did you mean user?\n", stderr.String())
+	assert.Empty(t, stdout.String())
+}
+
+func TestSuggest_NoCloseMatch(t *testing.T) {
+	assert.Equal(t, "", suggest("payments", []string{"auth", "user"}))
+}
+
+func TestLevenshtein(t *testing.T) {
+	assert.Equal(t, 0, levenshtein("auth", "auth"))
+	assert.Equal(t, 2, levenshtein("auht", "auth"))
+	assert.Equal(t, 3, levenshtein("", "abc"))
+}
@@ -0,0 +1,132 @@
+package project
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// Shard is the part Index of Total of the affected projects, run by one of Total CI nodes.
+type Shard struct {
+	Index int
+	Total int
+}
+
+// ParseShard parses a shard written as N/M, with 1 <= N <= M.
+func ParseShard(s string) (*Shard, error) {
+	index, total, found := strings.Cut(s, "/")
+	n, errIndex := strconv.Atoi(index)
+	m, errTotal := strconv.Atoi(total)
+	if !found || errIndex != nil || errTotal != nil || n < 1 || n > m {
+		return nil, fmt.Errorf("invalid shard %q, expected N/M with 1 <= N <= M", s)
+	}
+	return &Shard{Index: n, Total: m}, nil
+}
+
+func (s *Shard) String() string {
+	return fmt.Sprintf("%d/%d", s.Index, s.Total)
+}
+
+// shardPlan is the distribution of the affected projects over the shards.
+type shardPlan struct {
+	shards [][]*Project
+	// loads are the estimated durations of the shards, or their number of projects without history
+	loads      []time.Duration
+	byDuration bool
+}
+
+// planShards distributes the affected projects over total shards, the longest first on the least
+// loaded shard. Projects are weighted by the duration of their last build, projects without one by the
+// mean duration, and all the same when no project has one. The plan only depends on the projects,
+// so every CI node computes the same one.
+func planShards(projects []*Project, total int) *shardPlan {
+	var affected []*Project
+	for _, p := range projects {
+		if p.isAffected() {
+			affected = append(affected, p)
+		}
+	}
+
+	weights := map[*Project]time.Duration{}
+	var sum time.Duration
+	for _, p := range affected {
+		if d := p.lastBuildDuration(); d > 0 {
+			weights[p] = d
+			sum += d
+		}
+	}
+
+	plan := &shardPlan{
+		shards:     make([][]*Project, total),
+		loads:      make([]time.Duration, total),
+		byDuration: len(weights) > 0,
+	}
+
+	defaultWeight := time.Duration(1)
+	if plan.byDuration {
+		defaultWeight = sum / time.Duration(len(weights))
+	}
+	for _, p := range affected {
+		if _, ok := weights[p]; !ok {
+			weights[p] = defaultWeight
+		}
+	}
+
+	sort.Slice(affected, func(i, j int) bool {
+		if weights[affected[i]] != weights[affected[j]] {
+			return weights[affected[i]] > weights[affected[j]]
+		}
+		return affected[i].CleanPath < affected[j].CleanPath
+	})
+
+	for _, p := range affected {
+		shard := 0
+		for i := range plan.loads {
+			if plan.loads[i] < plan.loads[shard] {
+				shard = i
+			}
+		}
+		plan.shards[shard] = append(plan.shards[shard], p)
+		plan.loads[shard] += weights[p]
+	}
+
+	for _, shard := range plan.shards {
+		sort.Slice(shard, func(i, j int) bool { return shard[i].CleanPath < shard[j].CleanPath })
+	}
+
+	return plan
+}
+
+// lastBuildDuration returns the duration of the last build of the target, 0 if unknown.
+func (p *Project) lastBuildDuration() time.Duration {
+	if p.Cache == nil || p.Cache.Target[p.CMDOptions.Target] == nil {
+		return 0
+	}
+	return p.Cache.Target[p.CMDOptions.Target].Duration
+}
+
+func (sp *shardPlan) load(i int) string {
+	if sp.byDuration {
+		return fmt.Sprintf("~%s", sp.loads[i].Round(time.Second))
+	}
+	return fmt.Sprintf("%d projects", sp.loads[i])
+}
+
+func (sp *shardPlan) print(log Logger, current *Shard) {
+	log.Infof("Shard plan:\n")
+	for i, shard := range sp.shards {
+		name := fmt.Sprintf("%d/%d", i+1, len(sp.shards))
+		if i+1 == current.Index {
+			name = color.HiBlueString("%s", name)
+		}
+		log.Infof("  shard %s (%s):", name, sp.load(i))
+		for _, p := range shard {
+			log.Infof(" %s", p.Name)
+		}
+		log.Infof("\n")
+	}
+}
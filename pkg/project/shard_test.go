@@ -0,0 +1,106 @@
+package project
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseShard(t *testing.T) {
+	s, err := ParseShard("2/3")
+	assert.NoError(t, err)
+	assert.Equal(t, &Shard{Index: 2, Total: 3}, s)
+	assert.Equal(t, "2/3", s.String())
+
+	for _, invalid := range []string{"", "2", "0/3", "4/3", "a/3", "1/b", "-1/2"} {
+		_, err := ParseShard(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func newShardProject(name string, duration time.Duration) *Project {
+	opt := &Options{Target: TargetBuild, Force: true}
+	return &Project{
+		Name:       name,
+		CleanPath:  "services/" + name,
+		CMDOptions: opt,
+		Metadata:   &Metadata{},
+		Cache:      &Cache{Target: map[Target]*Metadata{TargetBuild: {Duration: duration}}},
+	}
+}
+
+func shardNames(plan *shardPlan) (names [][]string) {
+	for _, shard := range plan.shards {
+		shardNames := []string{}
+		for _, p := range shard {
+			shardNames = append(shardNames, p.Name)
+		}
+		names = append(names, shardNames)
+	}
+	return names
+}
+
+func TestPlanShards_BalancesDurations(t *testing.T) {
+	// Arrange
+	projects := []*Project{
+		newShardProject("a", 10*time.Minute),
+		newShardProject("b", 6*time.Minute),
+		newShardProject("c", 5*time.Minute),
+		newShardProject("d", 4*time.Minute),
+		newShardProject("e", time.Minute),
+	}
+
+	// Act
+	plan := planShards(projects, 2)
+
+	// Assert
+	assert.Equal(t, [][]string{{"a", "d"}, {"b", "c", "e"}}, shardNames(plan))
+	assert.Equal(t, []time.Duration{14 * time.Minute, 12 * time.Minute}, plan.loads)
+	assert.True(t, plan.byDuration)
+}
+
+func TestPlanShards_FallsBackToCounts(t *testing.T) {
+	projects := []*Project{newShardProject("c", 0), newShardProject("a", 0), newShardProject("b", 0)}
+
+	plan := planShards(projects, 2)
+
+	assert.Equal(t, [][]string{{"a", "c"}, {"b"}}, shardNames(plan))
+	assert.False(t, plan.byDuration)
+	assert.Equal(t, "2 projects", plan.load(0))
+}
+
+func TestPlanShards_UnknownDurationIsTheMean(t *testing.T) {
+	projects := []*Project{
+		newShardProject("a", 10*time.Minute),
+		newShardProject("b", 2*time.Minute),
+		newShardProject("new", 0),
+	}
+
+	plan := planShards(projects, 2)
+
+	assert.Equal(t, [][]string{{"a"}, {"b", "new"}}, shardNames(plan))
+	assert.Equal(t, []time.Duration{10 * time.Minute, 8 * time.Minute}, plan.loads)
+}
+
+func TestPlanShards_IsStableAndSkipsNotAffected(t *testing.T) {
+	// Arrange
+	projects := []*Project{
+		newShardProject("a", time.Minute),
+		newShardProject("b", time.Minute),
+		newShardProject("c", time.Minute),
+		newShardProject("d", time.Minute),
+	}
+	cached := newShardProject("cached", time.Hour)
+	cached.CMDOptions = &Options{Target: TargetBuild}
+	cached.Cache.Target[TargetBuild] = &Metadata{Duration: time.Hour}
+	reversed := []*Project{projects[3], projects[2], cached, projects[1], projects[0]}
+
+	// Act
+	plan := planShards(append(projects, cached), 3)
+	reversedPlan := planShards(reversed, 3)
+
+	// Assert
+	assert.Equal(t, [][]string{{"a", "d"}, {"b"}, {"c"}}, shardNames(plan))
+	assert.Equal(t, shardNames(plan), shardNames(reversedPlan))
+}
@@ -0,0 +1,152 @@
+package project
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+type ResultStatus string
+
+const (
+	ResultBuilt   ResultStatus = "built"
+	ResultCached  ResultStatus = "cached"
+	ResultSkipped ResultStatus = "skipped"
+	ResultFailed  ResultStatus = "failed"
+	// ResultAffected is the status of affected projects in a dry run.
+	ResultAffected ResultStatus = "affected"
+)
+
+// maxErrorExcerpt is the max length of the error printed in the summary table.
+const maxErrorExcerpt = 60
+
+// Result is the outcome of a project in an affected run.
+type Result struct {
+	Name     string        `json:"name"`
+	Path     string        `json:"path"`
+	Status   ResultStatus  `json:"status"`
+	Reason   string        `json:"reason,omitempty"`
+	Duration time.Duration `json:"-"`
+	Seconds  float64       `json:"seconds"`
+	Error    string        `json:"error,omitempty"`
+	Command  string        `json:"command,omitempty"`
+	Stdout   string        `json:"stdout,omitempty"`
+	Stderr   string        `json:"stderr,omitempty"`
+}
+
+// Summary is the outcome of an affected run, results are in the order of the projects.
+type Summary struct {
+	Target   Target        `json:"target"`
+	Results  []*Result     `json:"results"`
+	Duration time.Duration `json:"-"`
+	Seconds  float64       `json:"seconds"`
+}
+
+func newResult(p *Project, status ResultStatus, d time.Duration, err error) *Result {
+	r := &Result{
+		Name:     p.Name,
+		Path:     p.CleanPath,
+		Status:   status,
+		Duration: d,
+		Seconds:  d.Seconds(),
+	}
+	if err != nil {
+		r.Error = err.Error()
+	}
+
+	var buildErr *BuildError
+	if errors.As(err, &buildErr) {
+		r.Command, r.Stdout, r.Stderr = buildErr.Command, buildErr.Stdout, buildErr.Stderr
+	}
+	return r
+}
+
+// Count returns the number of results with the status.
+func (s *Summary) Count(status ResultStatus) (n int) {
+	for _, r := range s.Results {
+		if r.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// Err returns an error if a project failed.
+func (s *Summary) Err() error {
+	if failed := s.Count(ResultFailed); failed > 0 {
+		return fmt.Errorf("%d/%d projects failed", failed, len(s.Results))
+	}
+	return nil
+}
+
+// WriteJSON writes the summary to a JSON file.
+func (s *Summary) WriteJSON(file string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, append(data, '\n'), 0o644)
+}
+
+func (s *Summary) print(log Logger) {
+	log.Infof("Summary: %s built, %s cached, %s skipped, %s failed in %s\n",
+		color.GreenString("%d", s.Count(ResultBuilt)),
+		color.HiBlackString("%d", s.Count(ResultCached)),
+		color.YellowString("%d", s.Count(ResultSkipped)),
+		color.RedString("%d", s.Count(ResultFailed)),
+		s.Duration.Round(time.Millisecond))
+
+	// the cells are padded before the status is colored, the color codes would count in the widths
+	rows := [][]string{{"PROJECT", "PATH", "STATUS", "DURATION", "ERROR"}}
+	for _, r := range s.Results {
+		rows = append(rows, []string{r.Name, r.Path, string(r.Status), r.Duration.Round(time.Millisecond).String(), errorExcerpt(r.Error)})
+	}
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], len(cell))
+		}
+	}
+
+	var b strings.Builder
+	for i, row := range rows {
+		for j, cell := range row[:len(row)-1] {
+			cell = fmt.Sprintf("%-*s", widths[j]+2, cell)
+			if j == 2 && i > 0 {
+				cell = printResultStatus(s.Results[i-1].Status, cell)
+			}
+			b.WriteString(cell)
+		}
+		b.WriteString(row[len(row)-1])
+		b.WriteString("\n")
+	}
+
+	log.Infof("%s", b.String())
+}
+
+// printResultStatus colors text with the color of the status.
+func printResultStatus(s ResultStatus, text string) string {
+	switch s {
+	case ResultBuilt, ResultAffected:
+		return color.GreenString("%s", text)
+	case ResultSkipped:
+		return color.YellowString("%s", text)
+	case ResultFailed:
+		return color.RedString("%s", text)
+	}
+	return color.HiBlackString("%s", text)
+}
+
+// errorExcerpt returns the first line of the error, truncated.
+func errorExcerpt(err string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(err), "\n")
+	if len(line) > maxErrorExcerpt {
+		return line[:maxErrorExcerpt-3] + "..."
+	}
+	return line
+}
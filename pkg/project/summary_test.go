@@ -0,0 +1,75 @@
+package project
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/kperreau/goac/pkg/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummaryErr_NoFailure(t *testing.T) {
+	s := &Summary{Results: []*Result{{Status: ResultBuilt}, {Status: ResultCached}}}
+
+	assert.NoError(t, s.Err())
+}
+
+func TestSummaryWriteJSON(t *testing.T) {
+	// Arrange
+	file := filepath.Join(t.TempDir(), "summary.json")
+	s := &Summary{
+		Target:  TargetBuild,
+		Results: []*Result{{Name: "a", Path: "a", Status: ResultFailed, Duration: time.Second, Seconds: 1, Error: "boom"}},
+		Seconds: 2,
+	}
+
+	// Act
+	err := s.WriteJSON(file)
+
+	// Assert
+	assert.NoError(t, err)
+	data, err := os.ReadFile(file)
+	assert.NoError(t, err)
+
+	var decoded map[string]any
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, "build", decoded["target"])
+	assert.Equal(t, []any{map[string]any{"name": "a", "path": "a", "status": "failed", "seconds": 1.0, "error": "boom"}}, decoded["results"])
+}
+
+func TestSummaryPrint_AlignsColoredStatus(t *testing.T) {
+	// Arrange
+	oldNoColor := color.NoColor
+	color.NoColor = false
+	defer func() { color.NoColor = oldNoColor }()
+	var stderr bytes.Buffer
+	log := logger.New(logger.Options{Level: logger.LevelInfo, Stderr: &stderr})
+	s := &Summary{Results: []*Result{
+		{Name: "api", Path: "services/api", Status: ResultFailed, Duration: time.Second, Error: "boom"},
+		{Name: "worker", Path: "worker", Status: ResultCached},
+	}}
+
+	// Act
+	s.print(log)
+
+	// Assert
+	assert.Contains(t, stderr.String(), "\x1b[")
+	lines := strings.Split(regexp.MustCompile("\x1b\\[[0-9;]*m").ReplaceAllString(stderr.String(), ""), "\n")
+	assert.Equal(t, []string{
+		"PROJECT  PATH          STATUS  DURATION  ERROR",
+		"api      services/api  failed  1s        boom",
+		"worker   worker        cached  0s        ",
+	}, lines[1:4])
+}
+
+func TestErrorExcerpt(t *testing.T) {
+	assert.Equal(t, "exit status 1: first line", errorExcerpt("exit status 1: first line\nsecond line"))
+	assert.Len(t, errorExcerpt(string(make([]byte, 100))), maxErrorExcerpt)
+}
@@ -0,0 +1,223 @@
+package project
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/kperreau/goac/pkg/scan"
+	"github.com/kperreau/goac/pkg/watch"
+	"golang.org/x/mod/modfile"
+)
+
+// DefaultWatchDebounce is the time without changes waited before rebuilding.
+var DefaultWatchDebounce = 300 * time.Millisecond
+
+// Watch builds the affected projects, then rebuilds the projects whose hashed files change until ctx is done.
+// Changes are batched until no file changed for debounce. Only the hashes of the changed projects are
+// computed again, and their packages are listed again when a Go file or go.mod changes. Projects whose
+// hashes match the cache are not rebuilt.
+func (l *List) Watch(ctx context.Context, w watch.Watcher, debounce time.Duration) error {
+	changes := watch.Debounce(w.Changes(), debounce)
+
+	l.runWatch(ctx, l.Projects)
+	printed := false
+	for {
+		if err := w.Watch(l.watchedDirs()); err != nil {
+			return err
+		}
+		if !printed {
+			l.Options.logger().Infof("Watching %s projects for changes...\n", color.YellowString("%d", len(l.Projects)))
+			printed = true
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case files, ok := <-changes:
+			if !ok {
+				return errors.New("watcher closed")
+			}
+
+			files, err := l.relFiles(withoutCacheFiles(files, l.Options.cachePath()))
+			if err != nil {
+				l.Options.logger().Errorf("%s\n", err)
+				continue
+			}
+			// the created directories are watched before they are hashed, so that none of their files is missed
+			if err := w.Watch(l.watchedDirs()); err != nil {
+				return err
+			}
+			projects, err := l.reload(ctx, files)
+			if err != nil {
+				l.Options.logger().Errorf("%s\n", err)
+				continue
+			}
+			if l.runWatch(ctx, projects) {
+				printed = false
+			}
+		}
+	}
+}
+
+// runWatch builds the affected projects and reports if there were some, errors are printed and the watch goes on.
+func (l *List) runWatch(ctx context.Context, projects []*Project) bool {
+	var affected []*Project
+	for _, p := range projects {
+		if p.isAffected() {
+			affected = append(affected, p)
+		}
+	}
+	if len(affected) == 0 {
+		return false
+	}
+
+	run := &List{Projects: affected, Options: l.Options}
+	if err := run.Affected(ctx); err != nil && ctx.Err() == nil {
+		l.Options.logger().Errorf("%s\n", err)
+	}
+	return true
+}
+
+// watchedDirs returns the directories of the hashed files of every project and the subdirectories
+// of their inputs, joined to the root.
+func (l *List) watchedDirs() []string {
+	dirs := map[string]bool{}
+	for _, p := range l.Projects {
+		for _, pkg := range p.Module.Packages {
+			dirs[filepath.Clean(pkg.Dir)] = true
+			for _, file := range pkg.Files {
+				dirs[filepath.Dir(file)] = true
+			}
+		}
+
+		// the watchers are not recursive, every subdirectory of the inputs is watched to see the new files
+		for _, input := range p.inputs() {
+			dirs[input] = true
+			inputDirs, err := p.inputDirs(input, p.inputRule())
+			if err != nil {
+				continue
+			}
+			for _, dir := range inputDirs {
+				dirs[dir] = true
+			}
+		}
+	}
+
+	sorted := make([]string, 0, len(dirs))
+	for dir := range dirs {
+		sorted = append(sorted, l.Options.path(dir))
+	}
+	sort.Strings(sorted)
+	return withoutCacheFiles(sorted, l.Options.cachePath())
+}
+
+// relFiles returns the changed files relative to the root, like the project files.
+func (l *List) relFiles(files []string) ([]string, error) {
+	rel := make([]string, 0, len(files))
+	for _, file := range files {
+		relFile, err := relPath(l.Options.rootPath(), file)
+		if err != nil {
+			return nil, err
+		}
+		rel = append(rel, relFile)
+	}
+	return rel, nil
+}
+
+// reload computes again the hashes of the projects of which a file changed, and returns them.
+func (l *List) reload(ctx context.Context, files []string) ([]*Project, error) {
+	var projects []*Project
+	for _, p := range l.Projects {
+		if isModuleFileChanged(files) || slices.ContainsFunc(files, p.isInput) {
+			projects = append(projects, p)
+		}
+	}
+	if len(projects) == 0 {
+		return nil, nil
+	}
+
+	// go files may change the imports, and new ones are not listed yet
+	var gomod *modfile.File
+	reloadModules := isModuleFileChanged(files) || slices.ContainsFunc(files, func(file string) bool {
+		return filepath.Ext(file) == ".go"
+	})
+	if reloadModules {
+		var err error
+		if gomod, err = loadGOModFile(l.Options.rootPath()); err != nil {
+			return nil, err
+		}
+		if gomod, err = loadVendorModules(l.Options, gomod); err != nil {
+			return nil, err
+		}
+	}
+	for _, p := range projects {
+		if p.HashMemo != nil {
+			p.HashMemo.Forget(files...)
+		}
+
+		if reloadModules {
+			if err := p.LoadGOModules(ctx, gomod); err != nil {
+				return nil, err
+			}
+			if p.CMDOptions.DockerIgnore {
+				p.LoadRule(p.CMDOptions.Target)
+			}
+		}
+
+		if err := p.LoadHashs(); err != nil {
+			return nil, err
+		}
+	}
+
+	return projects, nil
+}
+
+// withoutCacheFiles removes the files of the goac cache in cachePath, written by the builds.
+func withoutCacheFiles(files []string, cachePath string) []string {
+	cacheDir := filepath.Clean(cachePath)
+	return slices.DeleteFunc(files, func(file string) bool {
+		return filepath.Clean(file) == cacheDir || strings.HasPrefix(filepath.Clean(file), cacheDir+string(filepath.Separator))
+	})
+}
+
+// isModuleFileChanged reports if a file changing the dependencies of every project changed.
+func isModuleFileChanged(files []string) bool {
+	return slices.ContainsFunc(files, func(file string) bool {
+		switch filepath.Base(file) {
+		case "go.mod", "go.sum", "go.work", "go.work.sum", "modules.txt":
+			return true
+		}
+		return false
+	})
+}
+
+// isInput reports if a changed file is hashed by the project, or may be once its packages are listed again.
+func (p *Project) isInput(file string) bool {
+	file = filepath.Clean(file)
+
+	for _, pkg := range p.Module.Packages {
+		if slices.Contains(pkg.Files, filepath.ToSlash(file)) {
+			return true
+		}
+		if filepath.Ext(file) == ".go" && filepath.Dir(file) == filepath.Clean(pkg.Dir) {
+			return len(scan.Filter([]string{file}, p.Rule)) > 0
+		}
+	}
+
+	if filepath.Ext(file) == ".go" {
+		return false
+	}
+	for _, input := range p.inputs() {
+		if input == "." || file == input || strings.HasPrefix(file, input+string(filepath.Separator)) {
+			return len(scan.Filter([]string{file}, p.inputRule())) > 0
+		}
+	}
+
+	return false
+}
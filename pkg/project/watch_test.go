@@ -0,0 +1,172 @@
+package project
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kperreau/goac/pkg/hasher"
+	"github.com/kperreau/goac/pkg/scan"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeWatcher reports the changes sent by the test.
+type fakeWatcher struct {
+	dirs    chan []string
+	changes chan string
+}
+
+func (w *fakeWatcher) Watch(dirs []string) error {
+	w.dirs <- dirs
+	return nil
+}
+
+func (w *fakeWatcher) Changes() <-chan string { return w.changes }
+
+func (w *fakeWatcher) Close() error { return nil }
+
+func newWatchProject(t *testing.T, dir string, name string) *Project {
+	t.Helper()
+
+	pkgDir := filepath.Join(dir, name)
+	assert.NoError(t, os.MkdirAll(pkgDir, 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(pkgDir, "data.txt"), []byte("v1"), 0o644))
+
+	hashPool := hasher.NewPool()
+	p := &Project{
+		Name:      name,
+		CleanPath: pkgDir,
+		HashPath:  name,
+		Target: map[Target]*TargetConfig{TargetBuild: {
+			Exec: &Exec{CMD: "sh", Params: []string{"-c", "echo built >> " + filepath.Join(dir, name+".log")}},
+		}},
+		Module: &Module{Packages: []*Package{
+			{ImportPath: name, Dir: pkgDir, Files: []string{filepath.Join(pkgDir, "data.txt")}},
+		}},
+		CMDOptions: &Options{Target: TargetBuild, MaxConcurrency: 1, KeepGoing: true},
+		HashPool:   hashPool,
+		HashMemo:   hasher.NewMemo(hashPool, 1),
+	}
+	assert.NoError(t, p.LoadCache())
+	assert.NoError(t, p.LoadHashs())
+	return p
+}
+
+func builds(t *testing.T, dir string, name string) int {
+	t.Helper()
+	data, _ := os.ReadFile(filepath.Join(dir, name+".log"))
+	return strings.Count(string(data), "built")
+}
+
+func TestWatch_RebuildsChangedProjectsOnly(t *testing.T) {
+	// Arrange
+	OldDefaultCachePath := DefaultCachePath
+	DefaultCachePath = t.TempDir() + "/"
+	defer func() { DefaultCachePath = OldDefaultCachePath }()
+
+	dir := t.TempDir()
+	api, worker := newWatchProject(t, dir, "api"), newWatchProject(t, dir, "worker")
+	l := &List{Projects: []*Project{api, worker}, Options: api.CMDOptions}
+	worker.CMDOptions = api.CMDOptions
+	w := &fakeWatcher{dirs: make(chan []string), changes: make(chan string)}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// Act
+	_, err := redirectAffectedStdout(func() error {
+		done := make(chan error)
+		go func() { done <- l.Watch(ctx, w, 10*time.Millisecond) }()
+
+		assert.Equal(t, []string{api.CleanPath, worker.CleanPath}, <-w.dirs)
+		assert.NoError(t, os.WriteFile(filepath.Join(api.CleanPath, "data.txt"), []byte("v2"), 0o644))
+		w.changes <- filepath.Join(api.CleanPath, "data.txt")
+		w.changes <- filepath.Join(dir, "unrelated.txt")
+		<-w.dirs // before hashing
+		<-w.dirs // after building
+
+		cancel()
+		return <-done
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 2, builds(t, dir, "api"))
+	assert.Equal(t, 1, builds(t, dir, "worker"))
+}
+
+func TestWatch_WatchesSubdirectoriesOfInputs(t *testing.T) {
+	// Arrange
+	OldDefaultCachePath := DefaultCachePath
+	DefaultCachePath = t.TempDir() + "/"
+	defer func() { DefaultCachePath = OldDefaultCachePath }()
+
+	dir := t.TempDir()
+	assets := filepath.Join(dir, "assets")
+	assert.NoError(t, os.MkdirAll(filepath.Join(assets, "icons"), 0o755))
+	api := newWatchProject(t, dir, "api")
+	api.Target[TargetBuild].Inputs = []string{assets}
+	assert.NoError(t, api.LoadHashs())
+	l := &List{Projects: []*Project{api}, Options: api.CMDOptions}
+	w := &fakeWatcher{dirs: make(chan []string), changes: make(chan string)}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// Act
+	_, err := redirectAffectedStdout(func() error {
+		done := make(chan error)
+		go func() { done <- l.Watch(ctx, w, 10*time.Millisecond) }()
+
+		assert.Equal(t, []string{api.CleanPath, assets, filepath.Join(assets, "icons")}, <-w.dirs)
+		// the subdirectory was created empty, its files are reported because it is watched
+		assert.NoError(t, os.WriteFile(filepath.Join(assets, "icons", "logo.svg"), []byte("<svg/>"), 0o644))
+		w.changes <- filepath.Join(assets, "icons", "logo.svg")
+		<-w.dirs // before hashing
+		<-w.dirs // after building
+
+		cancel()
+		return <-done
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 2, builds(t, dir, "api"))
+}
+
+func TestIsInput(t *testing.T) {
+	p := &Project{
+		Name:       "api",
+		CleanPath:  "services/api",
+		CMDOptions: &Options{Target: TargetBuildImage},
+		Module: &Module{Packages: []*Package{
+			{Dir: "services/api", Files: []string{"services/api/main.go", "services/api/static/index.html"}},
+			{Dir: "libs/shared", Files: []string{"libs/shared/shared.go"}},
+		}},
+		Rule: &scan.Rule{Excludes: []string{"*_test.go", "*.md"}},
+	}
+
+	assert.True(t, p.isInput("services/api/main.go"))
+	assert.True(t, p.isInput("services/api/static/index.html"))
+	assert.True(t, p.isInput("libs/shared/new.go"))
+	assert.True(t, p.isInput("services/api/Dockerfile"))
+	assert.False(t, p.isInput("libs/shared/shared_test.go"))
+	assert.False(t, p.isInput("services/api/README.md"))
+	assert.False(t, p.isInput("libs/shared/README"))
+	assert.False(t, p.isInput("services/worker/main.go"))
+}
+
+func TestWithoutCacheFiles(t *testing.T) {
+	OldDefaultCachePath := DefaultCachePath
+	DefaultCachePath = ".goac/cache/"
+	defer func() { DefaultCachePath = OldDefaultCachePath }()
+
+	files := withoutCacheFiles([]string{".goac/cache/abc.yaml", ".goac/cache", "main.go", ".goac/cache2/x"}, ".goac/cache/")
+
+	assert.Equal(t, []string{"main.go", ".goac/cache2/x"}, files)
+}
+
+func TestIsModuleFileChanged(t *testing.T) {
+	assert.True(t, isModuleFileChanged([]string{"main.go", "go.mod"}))
+	assert.True(t, isModuleFileChanged([]string{"vendor/modules.txt"}))
+	assert.False(t, isModuleFileChanged([]string{"main.go", "go.mod.bak"}))
+}
@@ -0,0 +1,125 @@
+package project
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+
+	"github.com/kperreau/goac/pkg/logger"
+)
+
+// Workspace is the API to embed goac in Go programs. Its methods return typed results instead
+// of printing them and never exit, the messages of the runs go to the logger of the workspace.
+// A workspace holds no state between calls, its methods can be called concurrently.
+type Workspace struct {
+	root      string
+	cachePath string
+	logger    Logger
+}
+
+// WorkspaceOptions configures a Workspace.
+type WorkspaceOptions struct {
+	// Root is the directory of the repository, the current directory if empty.
+	Root string
+	// CachePath is the cache directory, relative to Root. DefaultCachePath is used if empty.
+	CachePath string
+	// Logger receives the messages of the runs, they are discarded if nil.
+	Logger Logger
+}
+
+// NewWorkspace returns the workspace of the repository in opt.Root.
+func NewWorkspace(opt WorkspaceOptions) *Workspace {
+	w := &Workspace{root: opt.Root, cachePath: opt.CachePath, logger: opt.Logger}
+	if w.root == "" {
+		w.root = "."
+	}
+	if w.cachePath == "" {
+		w.cachePath = DefaultCachePath
+	}
+	if w.logger == nil {
+		w.logger = logger.Discard()
+	}
+	return w
+}
+
+// options returns a copy of opt run in the workspace, the concurrency defaults to the number of CPUs.
+func (w *Workspace) options(opt Options) *Options {
+	opt.RootPath, opt.CachePath, opt.Logger = w.root, w.cachePath, w.logger
+	if opt.Target == "" {
+		opt.Target = TargetNone
+	}
+	if opt.MaxConcurrency == 0 {
+		opt.MaxConcurrency = runtime.NumCPU()
+	}
+	return &opt
+}
+
+// Projects returns the projects selected by opt. With a target, their cache and hashes are loaded too.
+// If some projects fail to load, the others are returned with LoadErrors.
+func (w *Workspace) Projects(ctx context.Context, opt Options) ([]*Project, error) {
+	l, err := NewProjectsList(ctx, w.options(opt))
+	if l == nil {
+		return nil, err
+	}
+	return l.(*List).Projects, err
+}
+
+// Affected returns the projects affected for the target of opt with the reason they are affected,
+// without building them. If some projects fail to load, the affected ones among the others are
+// returned with LoadErrors.
+func (w *Workspace) Affected(ctx context.Context, opt Options) ([]*Result, error) {
+	l, err := w.targetList(ctx, opt)
+	if l == nil {
+		return nil, err
+	}
+	return l.AffectedProjects(), err
+}
+
+// Build builds the projects affected for the target of opt and returns the results of the run.
+// The error reports failed projects or an interrupted run, the summary is returned with it.
+// If some projects fail to load, nothing is built unless opt.KeepGoing is set, then the others
+// are built and the LoadErrors are joined to the error of the run.
+func (w *Workspace) Build(ctx context.Context, opt Options) (*Summary, error) {
+	l, loadErr := w.targetList(ctx, opt)
+	if l == nil || (loadErr != nil && !opt.KeepGoing) {
+		return nil, loadErr
+	}
+
+	err := l.Affected(ctx)
+	return l.Summary(), errors.Join(loadErr, err)
+}
+
+// Plan returns the plan of the projects affected for the target of opt, to be built later by Apply.
+func (w *Workspace) Plan(ctx context.Context, opt Options) (*Plan, error) {
+	l, err := w.targetList(ctx, opt)
+	if err != nil {
+		return nil, err
+	}
+	return l.Plan(), nil
+}
+
+// Apply builds the projects of a plan with its commands and returns the results of the run.
+// The target, the hashing options and the selection of opt are those of the plan, it is not applied
+// and an OutdatedPlanError is returned if the inputs of its projects changed since it was made.
+func (w *Workspace) Apply(ctx context.Context, plan *Plan, opt Options) (*Summary, error) {
+	l, err := NewPlanList(ctx, plan, w.options(opt))
+	if err != nil {
+		return nil, err
+	}
+
+	err = l.Affected(ctx)
+	return l.Summary(), err
+}
+
+// Graph returns the projects selected by opt with their dependencies and dependents.
+func (w *Workspace) Graph(ctx context.Context, opt Options) ([]*GraphNode, error) {
+	return LoadGraph(ctx, w.options(opt))
+}
+
+func (w *Workspace) targetList(ctx context.Context, opt Options) (IList, error) {
+	if opt.Target != TargetBuild && opt.Target != TargetBuildImage {
+		return nil, fmt.Errorf("invalid target %q", opt.Target)
+	}
+	return NewProjectsList(ctx, w.options(opt))
+}
@@ -0,0 +1,153 @@
+package project
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newWorkspaceRepo creates a go module with two projects, the worker importing a package of the api.
+func newWorkspaceRepo(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	build := "target:\n  build:\n    exec:\n      cmd: touch\n      params: [\"{{project-path}}/built\"]\n"
+	files := map[string]string{
+		"go.mod":                   "module example.com/ws\n\ngo 1.22\n",
+		"api/main.go":              "package main\n\nfunc main() {}\n",
+		"api/" + configFileName:    "version: 1.0\nname: api\n" + build,
+		"api/lib/lib.go":           "package lib\n",
+		"worker/main.go":           "package main\n\nimport _ \"example.com/ws/api/lib\"\n\nfunc main() {}\n",
+		"worker/" + configFileName: "version: 1.0\nname: worker\n" + build,
+	}
+	for file, content := range files {
+		assert.NoError(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(file)), 0o755))
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, file), []byte(content), 0o644))
+	}
+	return dir
+}
+
+func TestWorkspaceProjects_PathsRelativeToRoot(t *testing.T) {
+	// Arrange
+	w := NewWorkspace(WorkspaceOptions{Root: newWorkspaceRepo(t), CachePath: t.TempDir()})
+
+	// Act
+	projects, err := w.Projects(context.Background(), Options{NoDaemon: true})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, projects, 2)
+	for _, p := range projects {
+		assert.Equal(t, p.Name, p.CleanPath)
+	}
+}
+
+func TestWorkspaceProjects_ReturnsLoadedProjectsWithLoadErrors(t *testing.T) {
+	// Arrange
+	root := newWorkspaceRepo(t)
+	assert.NoError(t, os.MkdirAll(filepath.Join(root, "broken"), 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "broken", configFileName), []byte("name: [\n"), 0o644))
+	w := NewWorkspace(WorkspaceOptions{Root: root, CachePath: t.TempDir()})
+
+	for _, opt := range []Options{
+		{NoDaemon: true},
+		{Target: TargetBuild, NoDaemon: true},
+		{Target: TargetBuild, ProjectsName: []string{"api"}, WithDependents: true, NoDaemon: true},
+	} {
+		// Act
+		projects, err := w.Projects(context.Background(), opt)
+
+		// Assert
+		var loadErrs LoadErrors
+		assert.ErrorAs(t, err, &loadErrs)
+		assert.Len(t, loadErrs, 1)
+		names := make([]string, 0, len(projects))
+		for _, p := range projects {
+			names = append(names, p.Name)
+		}
+		assert.Equal(t, []string{"api", "worker"}, names)
+	}
+}
+
+func TestWorkspaceBuild_KeepGoingBuildsLoadedProjects(t *testing.T) {
+	// Arrange
+	root := newWorkspaceRepo(t)
+	assert.NoError(t, os.MkdirAll(filepath.Join(root, "broken"), 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "broken", configFileName), []byte("name: [\n"), 0o644))
+	w := NewWorkspace(WorkspaceOptions{Root: root, CachePath: t.TempDir()})
+
+	// Act
+	failFast, failFastErr := w.Build(context.Background(), Options{Target: TargetBuild, NoDaemon: true})
+	summary, err := w.Build(context.Background(), Options{Target: TargetBuild, KeepGoing: true, NoDaemon: true})
+
+	// Assert
+	var loadErrs LoadErrors
+	assert.ErrorAs(t, failFastErr, &loadErrs)
+	assert.Nil(t, failFast)
+	assert.ErrorAs(t, err, &loadErrs)
+	assert.Len(t, summary.Results, 2)
+	assert.FileExists(t, filepath.Join(root, "api", "built"))
+	assert.FileExists(t, filepath.Join(root, "worker", "built"))
+}
+
+func TestWorkspaceBuild_ReturnsResultsThenNothingAffected(t *testing.T) {
+	// Arrange
+	root := newWorkspaceRepo(t)
+	w := NewWorkspace(WorkspaceOptions{Root: root, CachePath: t.TempDir()})
+	opt := Options{Target: TargetBuild, NoDaemon: true}
+
+	// Act
+	affected, err := w.Affected(context.Background(), opt)
+	assert.NoError(t, err)
+	summary, err := w.Build(context.Background(), opt)
+	assert.NoError(t, err)
+	affectedAfter, err := w.Affected(context.Background(), opt)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, affected, 2)
+	assert.Equal(t, "not built yet", affected[0].Reason)
+	assert.Equal(t, 2, summary.Count(ResultBuilt))
+	assert.FileExists(t, filepath.Join(root, "api", "built"))
+	assert.FileExists(t, filepath.Join(root, "worker", "built"))
+	assert.Empty(t, affectedAfter)
+}
+
+func TestWorkspaceAffected_InvalidTarget(t *testing.T) {
+	w := NewWorkspace(WorkspaceOptions{Root: newWorkspaceRepo(t)})
+
+	_, err := w.Affected(context.Background(), Options{NoDaemon: true})
+
+	assert.Error(t, err)
+}
+
+func TestWorkspace_ConcurrentCalls(t *testing.T) {
+	// Arrange
+	workspaces := []*Workspace{
+		NewWorkspace(WorkspaceOptions{Root: newWorkspaceRepo(t), CachePath: t.TempDir()}),
+		NewWorkspace(WorkspaceOptions{Root: newWorkspaceRepo(t), CachePath: t.TempDir()}),
+	}
+
+	// Act
+	summaries := make([]*Summary, len(workspaces))
+	errs := make([]error, len(workspaces))
+	wg := sync.WaitGroup{}
+	for i, w := range workspaces {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			summaries[i], errs[i] = w.Build(context.Background(), Options{Target: TargetBuild, NoDaemon: true})
+		}()
+	}
+	wg.Wait()
+
+	// Assert
+	for i := range workspaces {
+		assert.NoError(t, errs[i])
+		assert.Equal(t, 2, summaries[i].Count(ResultBuilt))
+	}
+}
@@ -3,6 +3,7 @@ package scan
 import (
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 type Rule struct {
@@ -62,6 +63,30 @@ func subDir(dir string, rule *Rule) (files []string, err error) {
 	return files, nil
 }
 
+// SubDirs returns dir and its subdirectories, without the directories matching the excludes patterns
+// and their subdirectories.
+func SubDirs(dir string, rule *Rule) (dirs []string, err error) {
+	dir = filepath.Clean(dir)
+	err = filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() {
+			return nil
+		}
+		if path != dir && rule != nil && fileMatch(entry.Name(), rule.Excludes) {
+			return filepath.SkipDir
+		}
+
+		dirs = append(dirs, path)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return dirs, nil
+}
+
 func fileMatch(filename string, patterns []string) bool {
 	for _, pattern := range patterns {
 		match, err := filepath.Match(pattern, filename)
@@ -75,3 +100,35 @@ func fileMatch(filename string, patterns []string) bool {
 
 	return false
 }
+
+// Key identifies the rule, two rules with the same patterns have the same key.
+func (r *Rule) Key() string {
+	if r == nil {
+		return ""
+	}
+	return strings.Join(r.Includes, ",") + "|" + strings.Join(r.Excludes, ",")
+}
+
+// Filter returns the files not excluded by the rule, matching the excludes patterns
+// against the file name and each of its parent directories.
+func Filter(files []string, rule *Rule) (filtered []string) {
+	for _, file := range files {
+		if !isExcluded(file, rule) {
+			filtered = append(filtered, file)
+		}
+	}
+	return filtered
+}
+
+func isExcluded(file string, rule *Rule) bool {
+	if rule == nil {
+		return false
+	}
+
+	for _, name := range strings.Split(filepath.ToSlash(filepath.Clean(file)), "/") {
+		if name != "." && name != ".." && fileMatch(name, rule.Excludes) {
+			return true
+		}
+	}
+	return false
+}
@@ -2,6 +2,8 @@ package scan
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -156,3 +158,59 @@ func TestFileMatch_EmptyFilename_ReturnsFalse(t *testing.T) {
 	// Assert
 	assert.False(t, result)
 }
+
+func TestRuleKey_SamePatternsSameKey(t *testing.T) {
+	rule1 := &Rule{Includes: []string{"*.go"}, Excludes: []string{"*_test.go"}}
+	rule2 := &Rule{Includes: []string{"*.go"}, Excludes: []string{"*_test.go"}}
+	rule3 := &Rule{Includes: []string{"*.go"}}
+
+	assert.Equal(t, rule1.Key(), rule2.Key())
+	assert.NotEqual(t, rule1.Key(), rule3.Key())
+}
+
+func TestRuleKey_NilRule(t *testing.T) {
+	var rule *Rule
+
+	assert.Equal(t, "", rule.Key())
+}
+
+func TestFilter_ExcludesFilesAndDirectories(t *testing.T) {
+	// Arrange
+	rule := &Rule{Excludes: []string{"*_gen.go", "testdata"}}
+	files := []string{"pkg/a.go", "pkg/a_gen.go", "pkg/testdata/b.go", "../c.go"}
+
+	// Act
+	result := Filter(files, rule)
+
+	// Assert
+	assert.Equal(t, []string{"pkg/a.go", "../c.go"}, result)
+}
+
+func TestFilter_NilRule(t *testing.T) {
+	files := []string{"a.go", "b.go"}
+
+	assert.Equal(t, files, Filter(files, nil))
+}
+
+func TestSubDirs_SkipsExcludedDirectories(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	for _, sub := range []string{"a/b", "a/c", "vendor/d"} {
+		assert.NoError(t, os.MkdirAll(filepath.Join(dir, sub), 0o755))
+	}
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "a", "file.txt"), nil, 0o644))
+
+	// Act
+	dirs, err := SubDirs(dir, &Rule{Excludes: []string{"vendor"}})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, []string{dir, filepath.Join(dir, "a"), filepath.Join(dir, "a", "b"), filepath.Join(dir, "a", "c")}, dirs)
+}
+
+func TestSubDirs_DirectoryDoesNotExist(t *testing.T) {
+	dirs, err := SubDirs("/path/to/nonexistent/directory", nil)
+
+	assert.Error(t, err)
+	assert.Nil(t, dirs)
+}
@@ -0,0 +1,64 @@
+package trace
+
+import (
+	"encoding/json"
+	"os"
+)
+
+type chromeTrace struct {
+	TraceEvents []chromeEvent `json:"traceEvents"`
+}
+
+type chromeEvent struct {
+	Name string            `json:"name"`
+	Cat  string            `json:"cat,omitempty"`
+	Ph   string            `json:"ph"`
+	Ts   int64             `json:"ts"`
+	Dur  int64             `json:"dur,omitempty"`
+	Pid  int               `json:"pid"`
+	Tid  int               `json:"tid"`
+	Args map[string]string `json:"args,omitempty"`
+}
+
+// Chrome returns the spans as Chrome trace events, to open with chrome://tracing or Perfetto.
+// Each lane is a thread named after it, in order of first span.
+func (t *Tracer) Chrome() ([]byte, error) {
+	events := []chromeEvent{}
+	lanes := map[string]int{}
+	for _, s := range t.Spans() {
+		tid, ok := lanes[s.Lane]
+		if !ok {
+			tid = len(lanes) + 1
+			lanes[s.Lane] = tid
+			events = append(events, chromeEvent{
+				Name: "thread_name",
+				Ph:   "M",
+				Pid:  1,
+				Tid:  tid,
+				Args: map[string]string{"name": s.Lane},
+			})
+		}
+
+		events = append(events, chromeEvent{
+			Name: s.Name,
+			Cat:  "goac",
+			Ph:   "X",
+			Ts:   s.StartTime.Sub(t.start).Microseconds(),
+			Dur:  s.EndTime.Sub(s.StartTime).Microseconds(),
+			Pid:  1,
+			Tid:  tid,
+			Args: s.Attributes,
+		})
+	}
+
+	return json.MarshalIndent(chromeTrace{TraceEvents: events}, "", "  ")
+}
+
+// WriteChrome writes the spans as Chrome trace events to a file.
+func (t *Tracer) WriteChrome(file string) error {
+	data, err := t.Chrome()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, append(data, '\n'), 0o644)
+}
@@ -0,0 +1,43 @@
+package trace
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTracerChrome_OneThreadPerLane(t *testing.T) {
+	// Arrange
+	tracer := New()
+	start := tracer.start
+	tracer.spans = []*Span{
+		{Name: "load", Lane: "a", StartTime: start.Add(time.Millisecond), EndTime: start.Add(3 * time.Millisecond)},
+		{Name: "load", Lane: "b", StartTime: start.Add(2 * time.Millisecond), EndTime: start.Add(4 * time.Millisecond)},
+		{Name: "build", Lane: "a", StartTime: start.Add(3 * time.Millisecond), EndTime: start.Add(5 * time.Millisecond)},
+	}
+
+	// Act
+	data, err := tracer.Chrome()
+
+	// Assert
+	assert.NoError(t, err)
+	var trace chromeTrace
+	assert.NoError(t, json.Unmarshal(data, &trace))
+	assert.Len(t, trace.TraceEvents, 5)
+	assert.Equal(t, chromeEvent{Name: "thread_name", Ph: "M", Pid: 1, Tid: 1, Args: map[string]string{"name": "a"}}, trace.TraceEvents[0])
+	assert.Equal(t, chromeEvent{Name: "load", Cat: "goac", Ph: "X", Ts: 1000, Dur: 2000, Pid: 1, Tid: 1}, trace.TraceEvents[1])
+	assert.Equal(t, 2, trace.TraceEvents[3].Tid)
+	assert.Equal(t, chromeEvent{Name: "build", Cat: "goac", Ph: "X", Ts: 3000, Dur: 2000, Pid: 1, Tid: 1}, trace.TraceEvents[4])
+}
+
+func TestTracerWriteChrome(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "trace.json")
+
+	err := New().WriteChrome(file)
+
+	assert.NoError(t, err)
+	assert.FileExists(t, file)
+}
@@ -0,0 +1,161 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// otlpTimeout bounds the export to the collector, so that a missing collector does not block the run.
+const otlpTimeout = 5 * time.Second
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+// OTLP returns the spans as an OTLP/HTTP JSON export request. The spans are children
+// of a root span covering the whole run, named after the service.
+func (t *Tracer) OTLP(service string) ([]byte, error) {
+	traceID, err := randomID(16)
+	if err != nil {
+		return nil, err
+	}
+	rootID, err := randomID(8)
+	if err != nil {
+		return nil, err
+	}
+
+	spans := t.Spans()
+	end := time.Now()
+	for _, s := range spans {
+		if s.EndTime.After(end) {
+			end = s.EndTime
+		}
+	}
+
+	otlpSpans := []otlpSpan{{
+		TraceID:           traceID,
+		SpanID:            rootID,
+		Name:              service,
+		Kind:              1,
+		StartTimeUnixNano: unixNano(t.start),
+		EndTimeUnixNano:   unixNano(end),
+	}}
+	for _, s := range spans {
+		spanID, err := randomID(8)
+		if err != nil {
+			return nil, err
+		}
+
+		attributes := []otlpAttribute{{Key: "goac.lane", Value: otlpValue{StringValue: s.Lane}}}
+		keys := make([]string, 0, len(s.Attributes))
+		for key := range s.Attributes {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			attributes = append(attributes, otlpAttribute{Key: key, Value: otlpValue{StringValue: s.Attributes[key]}})
+		}
+
+		otlpSpans = append(otlpSpans, otlpSpan{
+			TraceID:           traceID,
+			SpanID:            spanID,
+			ParentSpanID:      rootID,
+			Name:              s.Name,
+			Kind:              1,
+			StartTimeUnixNano: unixNano(s.StartTime),
+			EndTimeUnixNano:   unixNano(s.EndTime),
+			Attributes:        attributes,
+		})
+	}
+
+	return json.Marshal(otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: []otlpAttribute{{Key: "service.name", Value: otlpValue{StringValue: service}}}},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: service}, Spans: otlpSpans}},
+	}}})
+}
+
+// ExportOTLP sends the spans to an OTLP/HTTP collector endpoint, e.g. http://localhost:4318/v1/traces.
+func (t *Tracer) ExportOTLP(ctx context.Context, endpoint string, service string) error {
+	data, err := t.OTLP(service)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, otlpTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("otlp export failed: %s", resp.Status)
+	}
+	return nil
+}
+
+func randomID(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
@@ -0,0 +1,51 @@
+package trace
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTracerExportOTLP(t *testing.T) {
+	// Arrange
+	var received otlpRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		body, _ := io.ReadAll(r.Body)
+		assert.NoError(t, json.Unmarshal(body, &received))
+	}))
+	defer server.Close()
+
+	tracer := New()
+	tracer.Start("goac", "build").SetAttribute("project", "goac").End()
+
+	// Act
+	err := tracer.ExportOTLP(context.Background(), server.URL, "goac")
+
+	// Assert
+	assert.NoError(t, err)
+	spans := received.ResourceSpans[0].ScopeSpans[0].Spans
+	assert.Len(t, spans, 2)
+	assert.Equal(t, "goac", spans[0].Name)
+	assert.Len(t, spans[0].TraceID, 32)
+	assert.Equal(t, "build", spans[1].Name)
+	assert.Equal(t, spans[0].SpanID, spans[1].ParentSpanID)
+	assert.Equal(t, spans[0].TraceID, spans[1].TraceID)
+	assert.Contains(t, spans[1].Attributes, otlpAttribute{Key: "project", Value: otlpValue{StringValue: "goac"}})
+}
+
+func TestTracerExportOTLP_ErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	err := New().ExportOTLP(context.Background(), server.URL, "goac")
+
+	assert.ErrorContains(t, err, "503")
+}
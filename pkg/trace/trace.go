@@ -0,0 +1,79 @@
+// Package trace records the spans of a goac run, and exports them as Chrome trace events or to an OTLP collector.
+package trace
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Tracer records spans, a nil tracer records nothing.
+type Tracer struct {
+	mu    sync.Mutex
+	start time.Time
+	spans []*Span
+}
+
+// Span is a timed phase of the run. Spans of the same lane never overlap, a lane is
+// usually a project, so that the lanes show the concurrency of the run.
+type Span struct {
+	Name       string
+	Lane       string
+	StartTime  time.Time
+	EndTime    time.Time
+	Attributes map[string]string
+
+	tracer *Tracer
+}
+
+func New() *Tracer {
+	return &Tracer{start: time.Now()}
+}
+
+// Start starts a span in a lane, it is recorded when it ends.
+func (t *Tracer) Start(lane string, name string) *Span {
+	if t == nil {
+		return nil
+	}
+
+	return &Span{Name: name, Lane: lane, StartTime: time.Now(), tracer: t}
+}
+
+// SetAttribute adds an attribute to the span.
+func (s *Span) SetAttribute(key string, value string) *Span {
+	if s == nil {
+		return nil
+	}
+
+	if s.Attributes == nil {
+		s.Attributes = map[string]string{}
+	}
+	s.Attributes[key] = value
+	return s
+}
+
+// End records the span.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+
+	s.EndTime = time.Now()
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	s.tracer.spans = append(s.tracer.spans, s)
+}
+
+// Spans returns the ended spans sorted by start time.
+func (t *Tracer) Spans() []*Span {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	spans := append([]*Span(nil), t.spans...)
+	sort.SliceStable(spans, func(i, j int) bool { return spans[i].StartTime.Before(spans[j].StartTime) })
+	return spans
+}
@@ -0,0 +1,37 @@
+package trace
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTracer_NilIsNoop(t *testing.T) {
+	var tracer *Tracer
+
+	span := tracer.Start("lane", "name").SetAttribute("key", "value")
+	span.End()
+
+	assert.Nil(t, span)
+	assert.Empty(t, tracer.Spans())
+}
+
+func TestTracer_RecordsEndedSpansByStart(t *testing.T) {
+	// Arrange
+	tracer := New()
+	first := tracer.Start("a", "first").SetAttribute("project", "a")
+	second := tracer.Start("b", "second")
+	tracer.Start("c", "not ended")
+
+	// Act
+	second.End()
+	first.End()
+	spans := tracer.Spans()
+
+	// Assert
+	assert.Len(t, spans, 2)
+	assert.Equal(t, "first", spans[0].Name)
+	assert.Equal(t, map[string]string{"project": "a"}, spans[0].Attributes)
+	assert.Equal(t, "second", spans[1].Name)
+	assert.False(t, spans[0].EndTime.Before(spans[0].StartTime))
+}
@@ -1,7 +1,10 @@
 package utils
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -153,3 +156,59 @@ func TestAppendIfNotExist_AddElementsToNonEmptySlice(t *testing.T) {
 	// Assert that the slice remains changed without duplication
 	assert.Equal(t, []string{"1", "2", "3", "4"}, slice)
 }
+
+func TestParseDuration_Days(t *testing.T) {
+	d, err := ParseDuration("2d")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 48*time.Hour, d)
+}
+
+func TestParseDuration_DaysAndHours(t *testing.T) {
+	d, err := ParseDuration("1d12h")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 36*time.Hour, d)
+}
+
+func TestParseDuration_StandardDuration(t *testing.T) {
+	d, err := ParseDuration("90m")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 90*time.Minute, d)
+}
+
+func TestParseDuration_Invalid(t *testing.T) {
+	_, err := ParseDuration("xd")
+
+	assert.Error(t, err)
+}
+
+func TestWriteFileAtomic_WritesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.yaml")
+
+	err := WriteFileAtomic(path, []byte("data"), 0o644)
+
+	assert.NoError(t, err)
+	data, _ := os.ReadFile(path)
+	assert.Equal(t, "data", string(data))
+	files, _ := os.ReadDir(filepath.Dir(path))
+	assert.Len(t, files, 1)
+}
+
+func TestWriteFileAtomic_ReplacesExistingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte("old data"), 0o644))
+
+	err := WriteFileAtomic(path, []byte("new"), 0o644)
+
+	assert.NoError(t, err)
+	data, _ := os.ReadFile(path)
+	assert.Equal(t, "new", string(data))
+}
+
+func TestWriteFileAtomic_InvalidDirectory(t *testing.T) {
+	err := WriteFileAtomic(filepath.Join(t.TempDir(), "not-exist", "file.yaml"), []byte("data"), 0o644)
+
+	assert.Error(t, err)
+}
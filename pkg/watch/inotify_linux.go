@@ -0,0 +1,135 @@
+//go:build linux
+
+package watch
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"golang.org/x/sys/unix"
+)
+
+const inotifyMask = unix.IN_MODIFY | unix.IN_CLOSE_WRITE | unix.IN_CREATE | unix.IN_DELETE |
+	unix.IN_MOVED_FROM | unix.IN_MOVED_TO | unix.IN_ONLYDIR
+
+// inotify watches directories with the inotify API of Linux.
+type inotify struct {
+	fd      int
+	file    *os.File
+	mu      sync.Mutex
+	watches map[int]string // watch descriptor => directory
+	dirs    map[string]int // directory => watch descriptor
+	changes chan string
+	done    chan struct{}
+	once    sync.Once
+}
+
+func newNotify() (Watcher, error) {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return nil, err
+	}
+
+	// a non-blocking file is read through the runtime poller, so that Close unblocks the reads
+	w := &inotify{
+		fd:      fd,
+		file:    os.NewFile(uintptr(fd), "inotify"),
+		watches: map[int]string{},
+		dirs:    map[string]int{},
+		changes: make(chan string, 128),
+		done:    make(chan struct{}),
+	}
+	go w.read()
+
+	return w, nil
+}
+
+func (w *inotify) Watch(dirs []string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	wanted := map[string]bool{}
+	for _, dir := range dirs {
+		wanted[filepath.Clean(dir)] = true
+	}
+
+	for dir, wd := range w.dirs {
+		if !wanted[dir] {
+			_, _ = unix.InotifyRmWatch(w.fd, uint32(wd))
+			delete(w.dirs, dir)
+			delete(w.watches, wd)
+		}
+	}
+
+	for dir := range wanted {
+		if _, ok := w.dirs[dir]; ok {
+			continue
+		}
+		wd, err := unix.InotifyAddWatch(w.fd, dir, inotifyMask)
+		if errors.Is(err, unix.ENOENT) || errors.Is(err, unix.ENOTDIR) {
+			continue
+		}
+		if err != nil {
+			return &os.PathError{Op: "inotify_add_watch", Path: dir, Err: err}
+		}
+		w.dirs[dir] = wd
+		w.watches[wd] = dir
+	}
+
+	return nil
+}
+
+func (w *inotify) Changes() <-chan string {
+	return w.changes
+}
+
+func (w *inotify) Close() error {
+	err := w.file.Close()
+	w.once.Do(func() { close(w.done) })
+	return err
+}
+
+func (w *inotify) read() {
+	defer close(w.changes)
+
+	buf := make([]byte, 64*(unix.SizeofInotifyEvent+unix.NAME_MAX+1))
+	for {
+		n, err := w.file.Read(buf)
+		if err != nil {
+			return
+		}
+
+		for offset := 0; offset+unix.SizeofInotifyEvent <= n; {
+			wd := int(int32(binary.NativeEndian.Uint32(buf[offset:])))
+			mask := binary.NativeEndian.Uint32(buf[offset+4:])
+			nameLen := int(binary.NativeEndian.Uint32(buf[offset+12:]))
+			name := strings.TrimRight(string(buf[offset+unix.SizeofInotifyEvent:offset+unix.SizeofInotifyEvent+nameLen]), "\x00")
+			offset += unix.SizeofInotifyEvent + nameLen
+
+			w.mu.Lock()
+			dir, ok := w.watches[wd]
+			if mask&unix.IN_IGNORED != 0 {
+				delete(w.watches, wd)
+				delete(w.dirs, dir)
+			}
+			w.mu.Unlock()
+			if !ok || mask&unix.IN_IGNORED != 0 {
+				continue
+			}
+
+			path := dir
+			if name != "" {
+				path = filepath.Join(dir, name)
+			}
+			select {
+			case w.changes <- path:
+			case <-w.done:
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,33 @@
+//go:build linux
+
+package watch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInotify(t *testing.T) {
+	w, err := newNotify()
+	assert.NoError(t, err)
+
+	testWatcher(t, w)
+}
+
+func TestInotify_UnwatchedDirectory(t *testing.T) {
+	// Arrange
+	w, err := newNotify()
+	assert.NoError(t, err)
+	defer w.Close()
+	watched, unwatched := t.TempDir(), t.TempDir()
+	assert.NoError(t, w.Watch([]string{watched, unwatched}))
+
+	// Act
+	assert.NoError(t, w.Watch([]string{watched}))
+	assert.NoError(t, writeFile(unwatched+"/main.go", "package main"))
+	assert.NoError(t, writeFile(watched+"/main.go", "package main"))
+
+	// Assert
+	assert.Equal(t, watched+"/main.go", <-w.Changes())
+}
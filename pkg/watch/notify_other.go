@@ -0,0 +1,9 @@
+//go:build !linux
+
+package watch
+
+import "errors"
+
+func newNotify() (Watcher, error) {
+	return nil, errors.ErrUnsupported
+}
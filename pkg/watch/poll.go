@@ -0,0 +1,147 @@
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// poller watches directories by listing them every interval.
+type poller struct {
+	interval time.Duration
+	mu       sync.Mutex
+	dirs     []string
+	files    map[string]fileState
+	changes  chan string
+	done     chan struct{}
+	once     sync.Once
+}
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+func newPoller(interval time.Duration) Watcher {
+	w := &poller{
+		interval: interval,
+		files:    map[string]fileState{},
+		changes:  make(chan string, 128),
+		done:     make(chan struct{}),
+	}
+	go w.poll()
+
+	return w
+}
+
+func (w *poller) Watch(dirs []string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	watched := map[string]bool{}
+	for _, dir := range w.dirs {
+		watched[dir] = true
+	}
+
+	wanted := map[string]bool{}
+	var added []string
+	w.dirs = nil
+	for _, dir := range dirs {
+		dir = filepath.Clean(dir)
+		if wanted[dir] {
+			continue
+		}
+		wanted[dir] = true
+		w.dirs = append(w.dirs, dir)
+		if !watched[dir] {
+			added = append(added, dir)
+		}
+	}
+
+	// the directories still watched keep their files, so that their changes since the last poll are reported,
+	// the files of the new directories are not reported as changed
+	files := listFiles(added)
+	for file, state := range w.files {
+		if wanted[filepath.Dir(file)] {
+			files[file] = state
+		}
+	}
+	w.files = files
+	return nil
+}
+
+func (w *poller) Changes() <-chan string {
+	return w.changes
+}
+
+func (w *poller) Close() error {
+	w.once.Do(func() { close(w.done) })
+	return nil
+}
+
+func (w *poller) poll() {
+	defer close(w.changes)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+		}
+
+		for _, change := range w.diff() {
+			select {
+			case w.changes <- change:
+			case <-w.done:
+				return
+			}
+		}
+	}
+}
+
+// diff lists the directories again and returns the created, modified and deleted files.
+func (w *poller) diff() (changes []string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	files := listFiles(w.dirs)
+	for file, state := range files {
+		if previous, ok := w.files[file]; !ok || previous != state {
+			changes = append(changes, file)
+		}
+	}
+	for file := range w.files {
+		if _, ok := files[file]; !ok {
+			changes = append(changes, file)
+		}
+	}
+	w.files = files
+
+	return changes
+}
+
+func listFiles(dirs []string) map[string]fileState {
+	files := map[string]fileState{}
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			// the subdirectories are only reported when they are created or deleted
+			if entry.IsDir() {
+				files[filepath.Join(filepath.Clean(dir), entry.Name())] = fileState{}
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+			files[filepath.Join(filepath.Clean(dir), entry.Name())] = fileState{modTime: info.ModTime(), size: info.Size()}
+		}
+	}
+	return files
+}
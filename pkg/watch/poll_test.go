@@ -0,0 +1,70 @@
+package watch
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeFile(file string, content string) error {
+	return os.WriteFile(file, []byte(content), 0o644)
+}
+
+func removeFile(file string) error {
+	return os.Remove(file)
+}
+
+func TestPoller(t *testing.T) {
+	testWatcher(t, newPoller(20*time.Millisecond))
+}
+
+func TestPoller_WatchDoesNotReportExistingFiles(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	assert.NoError(t, writeFile(dir+"/main.go", "package main"))
+	w := newPoller(10 * time.Millisecond)
+	defer w.Close()
+
+	// Act
+	assert.NoError(t, w.Watch([]string{dir}))
+
+	// Assert
+	select {
+	case change := <-w.Changes():
+		t.Fatalf("unexpected change %s", change)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestPoller_WatchKeepsChangesOfWatchedDirectories(t *testing.T) {
+	// Arrange
+	dir, other := t.TempDir(), t.TempDir()
+	assert.NoError(t, writeFile(dir+"/main.go", "package main"))
+	w := &poller{files: map[string]fileState{}}
+	assert.NoError(t, w.Watch([]string{dir}))
+
+	// Act
+	assert.NoError(t, writeFile(dir+"/other.go", "package main"))
+	assert.NoError(t, writeFile(other+"/main.go", "package main"))
+	assert.NoError(t, w.Watch([]string{dir, other}))
+	changes := w.diff()
+
+	// Assert
+	assert.Equal(t, []string{dir + "/other.go"}, changes)
+}
+
+func TestPoller_ReportsCreatedDirectories(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	w := &poller{files: map[string]fileState{}}
+	assert.NoError(t, w.Watch([]string{dir}))
+
+	// Act
+	assert.NoError(t, os.Mkdir(dir+"/assets", 0o755))
+	changes := w.diff()
+
+	// Assert
+	assert.Equal(t, []string{dir + "/assets"}, changes)
+}
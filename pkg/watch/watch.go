@@ -0,0 +1,82 @@
+package watch
+
+import (
+	"sort"
+	"time"
+)
+
+// Watcher reports the changed files of a set of directories, subdirectories are not watched
+// but their creation and removal are reported.
+type Watcher interface {
+	// Watch replaces the watched directories, missing directories are ignored.
+	Watch(dirs []string) error
+	// Changes returns the paths of the changed files, it is closed when the watcher is closed.
+	Changes() <-chan string
+	Close() error
+}
+
+// New returns a watcher using inotify on Linux. On other systems or when inotify is not available,
+// it returns a watcher polling the directories every interval.
+func New(interval time.Duration) Watcher {
+	if w, err := newNotify(); err == nil {
+		return w
+	}
+	return newPoller(interval)
+}
+
+// Debounce batches the changes until none happens for delay. Batches are sorted and without duplicates,
+// the returned channel is closed when changes is closed.
+func Debounce(changes <-chan string, delay time.Duration) <-chan []string {
+	batches := make(chan []string)
+
+	go func() {
+		defer close(batches)
+
+		pending := map[string]bool{}
+		timer := time.NewTimer(delay)
+		timer.Stop()
+		for {
+			select {
+			case change, ok := <-changes:
+				if !ok {
+					return
+				}
+				pending[change] = true
+				timer.Reset(delay)
+			case <-timer.C:
+				batch := make([]string, 0, len(pending))
+				for change := range pending {
+					batch = append(batch, change)
+				}
+				sort.Strings(batch)
+				pending = map[string]bool{}
+
+				// changes keep being read while the batch waits to be received
+				if !send(batches, batch, changes, pending, timer, delay) {
+					return
+				}
+			}
+		}
+	}()
+
+	return batches
+}
+
+// send sends the batch, recording the changes received meanwhile as pending. It returns false
+// if changes is closed.
+func send(batches chan<- []string, batch []string, changes <-chan string, pending map[string]bool,
+	timer *time.Timer, delay time.Duration,
+) bool {
+	for {
+		select {
+		case batches <- batch:
+			return true
+		case change, ok := <-changes:
+			if !ok {
+				return false
+			}
+			pending[change] = true
+			timer.Reset(delay)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package watch
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDebounce_BatchesChanges(t *testing.T) {
+	// Arrange
+	changes := make(chan string)
+	batches := Debounce(changes, 50*time.Millisecond)
+
+	// Act
+	changes <- "b.go"
+	changes <- "a.go"
+	changes <- "b.go"
+	first := <-batches
+	changes <- "c.go"
+	second := <-batches
+	close(changes)
+
+	// Assert
+	assert.Equal(t, []string{"a.go", "b.go"}, first)
+	assert.Equal(t, []string{"c.go"}, second)
+	_, ok := <-batches
+	assert.False(t, ok)
+}
+
+func TestDebounce_KeepsChangesWhileBatchIsPending(t *testing.T) {
+	changes := make(chan string)
+	batches := Debounce(changes, 20*time.Millisecond)
+
+	changes <- "a.go"
+	time.Sleep(100 * time.Millisecond)
+	// the first batch is not received yet, the change must not block
+	changes <- "b.go"
+
+	assert.Equal(t, []string{"a.go"}, <-batches)
+	assert.Equal(t, []string{"b.go"}, <-batches)
+	close(changes)
+}
+
+// testWatcher checks that a watcher reports the creation, the update and the removal of a file.
+func testWatcher(t *testing.T, w Watcher) {
+	t.Helper()
+	defer w.Close()
+
+	dir := t.TempDir()
+	file := dir + "/main.go"
+	assert.NoError(t, w.Watch([]string{dir, dir + "/missing"}))
+
+	expectChange := func(action func() error) {
+		t.Helper()
+		assert.NoError(t, action())
+		select {
+		case change := <-w.Changes():
+			assert.Equal(t, file, change)
+		case <-time.After(5 * time.Second):
+			t.Fatal("no change reported")
+		}
+		// drain the other events of the same action
+		for {
+			select {
+			case <-w.Changes():
+			case <-time.After(200 * time.Millisecond):
+				return
+			}
+		}
+	}
+
+	expectChange(func() error { return writeFile(file, "package main") })
+	expectChange(func() error { return writeFile(file, "package main\n\nfunc main() {}") })
+	expectChange(func() error { return removeFile(file) })
+
+	assert.NoError(t, w.Close())
+	for range w.Changes() {
+	}
+}